@@ -2,17 +2,38 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/mr1hm/disaster-alerts-bot/internal/bot"
 	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+	"github.com/mr1hm/disaster-alerts-bot/internal/filestamp"
+	"github.com/mr1hm/disaster-alerts-bot/internal/metrics"
+	"github.com/mr1hm/disaster-alerts-bot/internal/rules"
+	"github.com/mr1hm/disaster-alerts-bot/internal/tracing"
 )
 
 func main() {
+	backfillSince := flag.String("backfill-since", "", "Replay disasters since this time (RFC 3339, or a duration ago like 72h) into a channel and exit, without acknowledging upstream")
+	backfillUntil := flag.String("backfill-until", "", "End of the replay range (RFC 3339, or a duration ago), default now")
+	backfillChannel := flag.String("backfill-channel", "", "Channel to replay into, default DISCORD_CHANNEL_ID")
+	configPath := flag.String("config", "", "Path to a YAML config file; environment variables override its settings")
+	healthcheck := flag.Bool("healthcheck", false, "Check /healthz of the bot running on HTTP_ADDRESS and exit non-zero if unhealthy, for container health checks")
+	flag.Usage = usage
+	flag.Parse()
+
 	// Configure slog to use local time with JSON output
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
@@ -25,19 +46,59 @@ func main() {
 
 	_ = godotenv.Load() // ignore error if .env doesn't exist
 
-	cfg, err := config.Load()
+	if *healthcheck {
+		// Runs without --config in containers, so it mustn't need one
+		if err := checkHealth(healthAddress()); err != nil {
+			slog.Error("Health check failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	switch flag.Arg(0) {
+	case "validate-config":
+		if err := validateConfig(*configPath, flag.Args()[1:], os.Stdout); err != nil {
+			logConfigError(err)
+			os.Exit(1)
+		}
+		return
+	case "test-rule":
+		if err := testRule(*configPath, flag.Args()[1:], os.Stdout); err != nil {
+			var invalid *config.ValidationError
+			if errors.As(err, &invalid) {
+				logConfigError(err)
+			} else {
+				slog.Error("Rule test failed", "error", err)
+			}
+			os.Exit(1)
+		}
+		return
+	case "":
+		// Run the bot
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		slog.Error("Failed to load config", "error", err)
+		logConfigError(err)
 		os.Exit(1)
 	}
 
-	if cfg.Token == "" {
-		slog.Error("DISCORD_TOKEN is required")
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
 		os.Exit(1)
 	}
-	if cfg.ChannelID == "" {
-		slog.Error("DISCORD_CHANNEL_ID is required")
-		os.Exit(1)
+	defer flushTraces(shutdownTracing)
+
+	if *backfillSince != "" {
+		if err := runBackfill(cfg, *backfillSince, *backfillUntil, *backfillChannel); err != nil {
+			slog.Error("Backfill failed", "error", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	b, err := bot.New(cfg)
@@ -46,6 +107,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	httpServer := startHTTPServer(cfg.HTTPAddress, b)
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	sigCh := make(chan os.Signal, 1)
@@ -57,11 +120,285 @@ func main() {
 		cancel()
 	}()
 
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go watchConfig(ctx, *configPath, hupCh, b)
+
 	if err := b.Start(ctx); err != nil {
 		slog.Error("Bot error", "error", err)
 		os.Exit(1)
 	}
 
 	b.Stop()
+	stopHTTPServer(httpServer)
 	slog.Info("Bot stopped")
 }
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [validate-config | test-rule [-since 24h] RULE]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  validate-config  Print the effective configuration with secrets redacted and exit")
+	fmt.Fprintln(out, "  test-rule        Evaluate a route rule against recent disasters and print the matches")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// logConfigError logs each invalid setting on its own line.
+func logConfigError(err error) {
+	var invalid *config.ValidationError
+	if !errors.As(err, &invalid) {
+		slog.Error("Failed to load config", "error", err)
+		return
+	}
+	for _, fe := range invalid.Errors {
+		slog.Error("Invalid config", "field", fe.Field, "error", fe.Err)
+	}
+}
+
+// validateConfig loads the configuration the bot would run with and writes it
+// to w with secrets redacted. args may set -config again after the command.
+func validateConfig(configPath string, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to a YAML config file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	out, err := cfg.Redacted().Marshal()
+	if err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// testRule compiles a route rule and prints which recent disasters it
+// matches, without posting anything. args may set -config again after the
+// command.
+func testRule(configPath string, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("test-rule", flag.ContinueOnError)
+	fs.StringVar(&configPath, "config", configPath, "Path to a YAML config file")
+	sinceArg := fs.String("since", "24h", "Test disasters since this time (RFC 3339, or a duration ago like 72h)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: test-rule [-since 24h] RULE")
+	}
+
+	rule, err := rules.Compile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid rule: %w", err)
+	}
+	since, err := parseTime(*sinceArg, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid -since: %w", err)
+	}
+
+	cfg, err := config.LoadUpstream(configPath)
+	if err != nil {
+		return err
+	}
+	b, err := bot.NewUpstream(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting upstream: %w", err)
+	}
+	defer b.Stop()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	results, err := b.TestRule(ctx, rule, since)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tTYPE\tLEVEL\tMAGNITUDE\tPOPULATION\tTITLE")
+	matched := 0
+	for _, r := range results {
+		// Failed evaluations are listed too, since they never match
+		title := r.Disaster.Title
+		switch {
+		case r.Err != nil:
+			title += fmt.Sprintf(" (error: %v)", r.Err)
+		case r.Match:
+			matched++
+		default:
+			continue
+		}
+		d := r.Disaster
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f\t%d\t%s\n", time.Unix(d.Timestamp, 0).Format(time.RFC3339), d.Type, d.AlertLevel, d.Magnitude, d.AffectedPopulationCount, title)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\n%d of %d disasters since %s match\n", matched, len(results), since.Format(time.RFC3339))
+	return err
+}
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = 5 * time.Second
+
+// watchConfig reloads the filtering and routing settings into b on SIGHUP, and
+// whenever the config file at path changes, until ctx is done.
+func watchConfig(ctx context.Context, path string, hup <-chan os.Signal, b *bot.Bot) {
+	var poll <-chan time.Time
+	var last filestamp.Stamp
+	if path != "" {
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+		last, _ = filestamp.Stat(path)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("Received SIGHUP, reloading config")
+		case <-poll:
+			stamp, _ := filestamp.Stat(path) // Zero if unreadable, retried next poll
+			if stamp == last {
+				continue
+			}
+			last = stamp
+			slog.Info("Config file changed, reloading", "path", path)
+		}
+
+		cfg, err := config.Load(path)
+		if err != nil {
+			logConfigError(err)
+			slog.Error("Failed to reload config, keeping the current one")
+			continue
+		}
+		b.Reload(cfg)
+	}
+}
+
+// flushTraces sends any buffered spans before exit.
+func flushTraces(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+}
+
+// startHTTPServer serves /metrics, /healthz and /readyz on addr in the
+// background. It returns nil if addr is empty.
+func startHTTPServer(addr string, b *bot.Bot) *http.Server {
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", b.ServeHealth)
+	mux.HandleFunc("GET /readyz", b.ServeReady)
+
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		slog.Info("HTTP server listening", "address", addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "error", err)
+		}
+	}()
+	return srv
+}
+
+func stopHTTPServer(srv *http.Server) {
+	if srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("Failed to stop HTTP server", "error", err)
+	}
+}
+
+// healthAddress returns the address -healthcheck queries: HTTP_ADDRESS, or
+// the default when it is unset. The config file isn't read.
+func healthAddress() string {
+	if addr, ok := os.LookupEnv("HTTP_ADDRESS"); ok {
+		return addr
+	}
+	return config.DefaultHTTPAddress
+}
+
+// checkHealth queries /healthz of a bot listening on addr.
+func checkHealth(addr string) error {
+	if addr == "" {
+		return errors.New("HTTP_ADDRESS is disabled")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_ADDRESS: %w", err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + net.JoinHostPort(host, port) + "/healthz")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// runBackfill replays a historical range into a channel. It leaves the
+// posted-message store alone so a running bot is unaffected.
+func runBackfill(cfg *config.Config, sinceArg, untilArg, channelID string) error {
+	now := time.Now()
+	since, err := parseTime(sinceArg, now)
+	if err != nil {
+		return fmt.Errorf("invalid --backfill-since: %w", err)
+	}
+	until := now
+	if untilArg != "" {
+		if until, err = parseTime(untilArg, now); err != nil {
+			return fmt.Errorf("invalid --backfill-until: %w", err)
+		}
+	}
+	if channelID == "" {
+		channelID = cfg.ChannelID
+	}
+	if channelID == "" {
+		return errors.New("--backfill-channel is required without DISCORD_CHANNEL_ID")
+	}
+
+	cfg.StorePath = ""
+	b, err := bot.New(cfg)
+	if err != nil {
+		return fmt.Errorf("creating bot: %w", err)
+	}
+	defer b.Stop()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	_, err = b.Backfill(ctx, since, until, channelID)
+	return err
+}
+
+// parseTime accepts an RFC 3339 timestamp or a duration before now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
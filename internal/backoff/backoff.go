@@ -0,0 +1,60 @@
+package backoff
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how retry delays grow. The delay before attempt n is
+// Initial * Multiplier^(n-1), capped at Max, then randomized by up to ±Jitter
+// (a fraction of the delay) so that many clients don't retry in lockstep.
+type Policy struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+
+	// rand returns a number in [0, 1). Overridden in tests.
+	rand func() float64
+}
+
+// Delay returns how long to wait before the given attempt, starting at 1.
+func (p Policy) Delay(attempt int) time.Duration {
+	d := float64(p.Initial)
+	mult := max(p.Multiplier, 1)
+	for i := 1; i < attempt; i++ {
+		d *= mult
+		if p.Max > 0 && d >= float64(p.Max) {
+			break
+		}
+	}
+	if p.Max > 0 {
+		d = min(d, float64(p.Max))
+	}
+
+	if p.Jitter > 0 {
+		r := rand.Float64
+		if p.rand != nil {
+			r = p.rand
+		}
+		d *= 1 + p.Jitter*(2*r()-1)
+	}
+	if p.Max > 0 {
+		d = min(d, float64(p.Max))
+	}
+	return time.Duration(max(d, 0))
+}
+
+// Sleep waits for d, returning ctx.Err() early if ctx is done first.
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
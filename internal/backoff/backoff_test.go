@@ -0,0 +1,78 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	p := Policy{Initial: time.Second, Max: 30 * time.Second, Multiplier: 2}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := p.Delay(tt.attempt); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy_Delay_Jitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		rand    float64
+		attempt int
+		want    time.Duration
+	}{
+		{"lowest", 0, 1, 8 * time.Second},
+		{"middle", 0.5, 1, 10 * time.Second},
+		{"highest", 0.99, 1, 11960 * time.Millisecond},
+		{"capped at max", 0.99, 3, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Policy{Initial: 10 * time.Second, Max: 30 * time.Second, Multiplier: 2, Jitter: 0.2}
+			p.rand = func() float64 { return tt.rand }
+			if got := p.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Delay_NoMultiplier(t *testing.T) {
+	p := Policy{Initial: 5 * time.Second}
+	if got := p.Delay(10); got != 5*time.Second {
+		t.Errorf("Delay(10) = %v, want constant 5s", got)
+	}
+}
+
+func TestSleep(t *testing.T) {
+	if err := Sleep(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("Sleep() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := Sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Sleep() error = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Sleep() did not return when ctx was cancelled")
+	}
+}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/metrics"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+const (
+	ackBatchSize     = 50
+	ackFlushInterval = 2 * time.Second
+	ackStopTimeout   = 5 * time.Second
+)
+
+// queueAck notes that a posted disaster is waiting to be acknowledged. The ack
+// loop flushes once a full batch is waiting, or on its next tick otherwise.
+func (b *Bot) queueAck() {
+	if b.ackPending.Add(1) >= ackBatchSize {
+		wake(b.ackWake)
+	}
+}
+
+// runAcks acknowledges posted disasters upstream in batches until ctx is
+// done, retrying with backoff while the server is unreachable. Anything still
+// pending at shutdown is flushed by Stop.
+func (b *Bot) runAcks(ctx context.Context) {
+	failures := 0
+
+	// Flush acks left over from the last run right away
+	b.ackPending.Add(1)
+	for {
+		wait := ackFlushInterval
+		if b.ackPending.Load() > 0 || failures > 0 {
+			if err := b.flushAcks(ctx); err != nil {
+				failures++
+				wait = outboxBackoff(failures)
+				slog.Error("Failed to acknowledge disasters, will retry", "error", err, "attempt", failures, "retry_in", wait)
+			} else {
+				failures = 0
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-b.ackWake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// flushAcks acknowledges every posted but unacknowledged disaster, up to
+// ackBatchSize per request. It stops at the first failed batch; the rest stay
+// unacked in the store for the next flush.
+func (b *Bot) flushAcks(ctx context.Context) error {
+	b.ackPending.Store(0)
+
+	recs, err := b.store.Unacked()
+	if err != nil {
+		return fmt.Errorf("listing unacked disasters: %w", err)
+	}
+
+	ids := make([]string, len(recs))
+	for i, rec := range recs {
+		ids[i] = rec.DisasterID
+	}
+
+	for len(ids) > 0 {
+		n := min(len(ids), ackBatchSize)
+		if err := b.acknowledgeDisasters(ctx, ids[:n]); err != nil {
+			return err
+		}
+		ids = ids[n:]
+	}
+	return nil
+}
+
+// acknowledgeDisasters marks a batch of disasters as sent upstream and
+// records the ack in the store.
+func (b *Bot) acknowledgeDisasters(ctx context.Context, ids []string) error {
+	// Link back to each disaster's delivery so its trace shows when it was acked
+	var links []trace.Link
+	for _, id := range ids {
+		if sc, ok := b.ackLinks.Load(id); ok {
+			links = append(links, trace.Link{SpanContext: sc.(trace.SpanContext)})
+		}
+	}
+	ctx, span := tracer.Start(ctx, "disasters.acknowledge",
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("disasters.count", len(ids))),
+	)
+	defer span.End()
+
+	_, err := b.client.AcknowledgeDisasters(ctx, &disastersv1.AcknowledgeDisastersRequest{
+		Ids: ids,
+	})
+	if err != nil {
+		metrics.AckFailures.Inc()
+		spanError(span, err)
+		return fmt.Errorf("acknowledging %d disasters: %w", len(ids), err)
+	}
+	for _, id := range ids {
+		b.ackLinks.Delete(id)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.store.MarkAcked(ids); err != nil {
+		return fmt.Errorf("recording acks: %w", err)
+	}
+
+	slog.Debug("Acknowledged disasters", "count", len(ids))
+	return nil
+}
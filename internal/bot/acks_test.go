@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+)
+
+func TestBot_FlushAcks(t *testing.T) {
+	client := &fakeClient{err: errors.New("upstream down")}
+	b := &Bot{client: client, store: store.NewMemory()}
+
+	_ = b.store.Put(&store.Record{DisasterID: "acked", Acked: true})
+	_ = b.store.Put(&store.Record{DisasterID: "pending"})
+
+	if err := b.flushAcks(context.Background()); err == nil {
+		t.Fatal("flushAcks() error = nil, want upstream error")
+	}
+	if b.postedRecord("pending").Acked {
+		t.Error("record marked acked although the ack failed")
+	}
+
+	client.err = nil
+	if err := b.flushAcks(context.Background()); err != nil {
+		t.Fatalf("flushAcks() error = %v", err)
+	}
+	if acked := client.ackedIDs(); !slices.Equal(acked, []string{"pending"}) {
+		t.Errorf("acked = %v, want [pending]", acked)
+	}
+	if !b.postedRecord("pending").Acked {
+		t.Error("pending record not marked acked")
+	}
+}
+
+func TestBot_FlushAcks_Batches(t *testing.T) {
+	client := &fakeClient{}
+	b := &Bot{client: client, store: store.NewMemory()}
+
+	for i := range 2*ackBatchSize + 1 {
+		_ = b.store.Put(&store.Record{DisasterID: fmt.Sprintf("d-%d", i)})
+	}
+
+	if err := b.flushAcks(context.Background()); err != nil {
+		t.Fatalf("flushAcks() error = %v", err)
+	}
+	if client.ackCalls != 3 {
+		t.Errorf("ack requests = %d, want 3", client.ackCalls)
+	}
+	if acked := client.ackedIDs(); len(acked) != 2*ackBatchSize+1 {
+		t.Errorf("acked %d disasters, want %d", len(acked), 2*ackBatchSize+1)
+	}
+	if recs, _ := b.store.Unacked(); len(recs) != 0 {
+		t.Errorf("Unacked() = %d records, want none", len(recs))
+	}
+}
+
+func TestBot_QueueAck_WakesOnFullBatch(t *testing.T) {
+	b := &Bot{ackWake: make(chan struct{}, 1)}
+
+	for range ackBatchSize - 1 {
+		b.queueAck()
+	}
+	select {
+	case <-b.ackWake:
+		t.Fatal("ack loop woken before a full batch")
+	default:
+	}
+
+	b.queueAck()
+	select {
+	case <-b.ackWake:
+	default:
+		t.Error("ack loop not woken on a full batch")
+	}
+}
+
+func TestBot_Stop_FlushesAcks(t *testing.T) {
+	client := &fakeClient{}
+	b := &Bot{client: client, store: store.NewMemory(), stopWork: func() {}}
+	_ = b.store.Put(&store.Record{DisasterID: "pending"})
+	b.queueAck()
+
+	b.Stop()
+
+	if acked := client.ackedIDs(); !slices.Equal(acked, []string{"pending"}) {
+		t.Errorf("acked = %v, want [pending] flushed on stop", acked)
+	}
+}
@@ -0,0 +1,220 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+const (
+	listPageSize = 50
+	listMaxLimit = 10000
+
+	// gapFillOverlap widens the gap-fill window before the last seen
+	// timestamp, since disasters can reach the server after newer ones.
+	gapFillOverlap = time.Hour
+)
+
+// errListTruncated means more disasters matched a listing than listMaxLimit.
+// The API has no upper time bound to page backwards with, so the oldest ones
+// can't be fetched.
+var errListTruncated = errors.New("too many disasters to list")
+
+// listDisastersSince returns every disaster with a timestamp at or after
+// since, oldest first. The server only returns the newest Limit matches, so
+// starting from the configured page size the limit is doubled until a
+// response comes back short, up to listMaxLimit. Past that it returns the
+// newest listMaxLimit disasters with errListTruncated.
+func (b *Bot) listDisastersSince(ctx context.Context, since int64, discordSent *bool) ([]*disastersv1.Disaster, error) {
+	limit := int32(listPageSize)
+	if cfg := b.cfg(); cfg != nil && cfg.BackfillPageSize > 0 {
+		limit = min(cfg.BackfillPageSize, listMaxLimit)
+	}
+	for {
+		resp, err := b.client.ListDisasters(ctx, &disastersv1.ListDisastersRequest{
+			Limit:       limit,
+			Since:       &since,
+			DiscordSent: discordSent,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		disasters := resp.Disasters
+		if len(disasters) < int(limit) || limit >= listMaxLimit {
+			sort.SliceStable(disasters, func(i, j int) bool { return disasters[i].Timestamp < disasters[j].Timestamp })
+			if len(disasters) >= listMaxLimit {
+				return disasters, fmt.Errorf("%w: more than %d since %s", errListTruncated, listMaxLimit, time.Unix(since, 0).UTC().Format(time.RFC3339))
+			}
+			return disasters, nil
+		}
+		limit = min(limit*2, listMaxLimit)
+	}
+}
+
+// streamRequest is the stream the bot subscribes to. It sets no filters, so
+// the server sends every disaster it ingests, GREEN ones included.
+func streamRequest() *disastersv1.StreamDisastersRequest {
+	return &disastersv1.StreamDisastersRequest{}
+}
+
+// streamMatches reports whether the server would send d on a stream opened
+// with req, applying the same filters as StreamDisasters.
+func streamMatches(req *disastersv1.StreamDisastersRequest, d *disastersv1.Disaster) bool {
+	if req.Type != nil && *req.Type != disastersv1.DisasterType_UNSPECIFIED && d.Type != *req.Type {
+		return false
+	}
+	if req.MinMagnitude != nil && d.Magnitude < *req.MinMagnitude {
+		return false
+	}
+	if req.AlertLevel != nil && *req.AlertLevel != disastersv1.AlertLevel_UNKNOWN && d.AlertLevel != *req.AlertLevel {
+		return false
+	}
+	if req.MinAlertLevel != nil && *req.MinAlertLevel != disastersv1.AlertLevel_UNKNOWN && d.AlertLevel < *req.MinAlertLevel {
+		return false
+	}
+	return true
+}
+
+// streamed keeps the listed disasters the stream would have delivered, so a
+// disaster reaches the same channels whether it was streamed or caught up on
+// by a gap fill or the startup fetch.
+func streamed(disasters []*disastersv1.Disaster) []*disastersv1.Disaster {
+	req := streamRequest()
+	var out []*disastersv1.Disaster
+	for _, d := range disasters {
+		if streamMatches(req, d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// gapFill posts anything the stream missed while disconnected: disasters since
+// the last one received that the stream would have delivered, deduplicated
+// through the store like the stream.
+func (b *Bot) gapFill(ctx context.Context) {
+	last := b.lastSeen.Load()
+	if last == 0 {
+		return
+	}
+	since := last - int64(gapFillOverlap/time.Second)
+
+	disasters, err := b.listDisastersSince(ctx, since, nil)
+	if errors.Is(err, errListTruncated) {
+		// Post what was fetched rather than nothing
+		slog.Warn("Stream gap too large, skipping the oldest disasters", "since", since, "error", err)
+	} else if err != nil {
+		slog.Error("Failed to fill stream gap", "since", since, "error", err)
+		return
+	}
+
+	for _, d := range disasters {
+		b.observe(d.Timestamp)
+	}
+
+	posted := 0
+	for _, d := range streamed(disasters) {
+		if b.handleDisaster(ctx, d) > 0 {
+			posted++
+		}
+	}
+
+	slog.Info("Filled stream gap", "since", since, "fetched", len(disasters), "posted", posted)
+}
+
+// observe records ts as the latest disaster timestamp seen, if it is.
+func (b *Bot) observe(ts int64) {
+	for {
+		last := b.lastSeen.Load()
+		if ts <= last || b.lastSeen.CompareAndSwap(last, ts) {
+			return
+		}
+	}
+}
+
+// seenPayload is a disaster payload handled recently, remembered by
+// firstSeen.
+type seenPayload struct {
+	hash      string
+	timestamp int64
+}
+
+// firstSeen reports whether d's payload is handled for the first time, so it
+// isn't counted again in the received and filtered metrics when a gap fill
+// re-fetches it. Only disasters recent enough for a gap fill to fetch again
+// are remembered.
+func (b *Bot) firstSeen(d *disastersv1.Disaster) bool {
+	b.outboxMu.Lock()
+	defer b.outboxMu.Unlock()
+
+	cutoff := b.lastSeen.Load() - int64(gapFillOverlap/time.Second)
+	for id, p := range b.seen {
+		if p.timestamp < cutoff {
+			delete(b.seen, id)
+		}
+	}
+	if d.Timestamp < cutoff {
+		return true
+	}
+
+	hash := store.PayloadHash(d)
+	if p, ok := b.seen[d.Id]; ok && p.hash == hash {
+		return false
+	}
+	if b.seen == nil {
+		b.seen = make(map[string]seenPayload)
+	}
+	b.seen[d.Id] = seenPayload{hash: hash, timestamp: d.Timestamp}
+	return true
+}
+
+// Backfill replays the disasters between since and until that pass the
+// default channel filter into channelID, oldest first. It is for manual
+// replays: nothing is recorded in the store or acknowledged upstream, and
+// subscriptions are ignored. It returns how many disasters were posted.
+//
+// The listing runs from since to now, so a range too far back to list in
+// full fails before anything is posted rather than skipping its oldest part.
+func (b *Bot) Backfill(ctx context.Context, since, until time.Time, channelID string) (int, error) {
+	listed, err := b.listDisastersSince(ctx, since.Unix(), nil)
+	var disasters []*disastersv1.Disaster
+	for _, d := range listed {
+		if d.Timestamp <= until.Unix() {
+			disasters = append(disasters, d)
+		}
+	}
+	if errors.Is(err, errListTruncated) {
+		return 0, fmt.Errorf("listing disasters: %w; only %d of the range could be fetched, use a later --backfill-since", err, len(disasters))
+	} else if err != nil {
+		return 0, fmt.Errorf("listing disasters: %w", err)
+	}
+
+	posted, failed := 0, 0
+	for _, d := range disasters {
+		if ctx.Err() != nil {
+			return posted, ctx.Err()
+		}
+		if !b.shouldPost(d) {
+			continue
+		}
+		if _, err := b.postDisaster(ctx, route{channelID: channelID}, d); err != nil {
+			slog.Error("Failed to post disaster", "id", d.Id, "channel_id", channelID, "error", err)
+			failed++
+			continue
+		}
+		posted++
+	}
+
+	slog.Info("Backfill complete", "since", since, "until", until, "fetched", len(disasters), "posted", posted, "failed", failed, "channel_id", channelID)
+	if failed > 0 {
+		return posted, fmt.Errorf("%d disasters failed to post", failed)
+	}
+	return posted, nil
+}
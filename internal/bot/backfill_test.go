@@ -0,0 +1,308 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ewohltman/discordgo-mock/mockconstants"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+	"github.com/mr1hm/disaster-alerts-bot/internal/metrics"
+	"github.com/mr1hm/disaster-alerts-bot/internal/subscriptions"
+)
+
+func TestBot_ListDisastersSince_WidensLimit(t *testing.T) {
+	client := &fakeClient{}
+	for i := range 120 {
+		client.disasters = append(client.disasters, &disastersv1.Disaster{Id: fmt.Sprintf("d-%d", i), Timestamp: int64(1000 + i)})
+	}
+	b := &Bot{client: client}
+
+	got, err := b.listDisastersSince(context.Background(), 1010, nil)
+	if err != nil {
+		t.Fatalf("listDisastersSince() error = %v", err)
+	}
+	if len(got) != 110 {
+		t.Fatalf("listDisastersSince() returned %d disasters, want 110", len(got))
+	}
+	if got[0].Id != "d-10" || got[len(got)-1].Id != "d-119" {
+		t.Errorf("listDisastersSince() = %s..%s, want oldest first", got[0].Id, got[len(got)-1].Id)
+	}
+	// 50 and 100 come back full, 200 comes back short
+	if client.listCalls != 3 {
+		t.Errorf("ListDisasters calls = %d, want 3", client.listCalls)
+	}
+}
+
+func TestBot_ListDisastersSince_SinglePage(t *testing.T) {
+	client := &fakeClient{disasters: []*disastersv1.Disaster{{Id: "d-1", Timestamp: 2000}}}
+	b := &Bot{client: client}
+
+	got, err := b.listDisastersSince(context.Background(), 1000, nil)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("listDisastersSince() = %v, %v, want one disaster", got, err)
+	}
+	if client.listCalls != 1 {
+		t.Errorf("ListDisasters calls = %d, want 1", client.listCalls)
+	}
+}
+
+func TestBot_GapFill(t *testing.T) {
+	channelID := mockconstants.TestChannel
+	session := newMockSession(t, channelID)
+
+	now := time.Now().Unix()
+	overlap := int64(gapFillOverlap / time.Second)
+	red := disastersv1.AlertLevel_RED
+
+	client := &fakeClient{disasters: []*disastersv1.Disaster{
+		{Id: "before-gap", Type: disastersv1.DisasterType_FLOOD, AlertLevel: red, Timestamp: now - overlap - 600},
+		{Id: "already-posted", Type: disastersv1.DisasterType_FLOOD, AlertLevel: red, Timestamp: now - 60},
+		{Id: "missed", Type: disastersv1.DisasterType_FLOOD, AlertLevel: red, Timestamp: now + 30},
+	}}
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.client = client
+	b.cfg().ChannelID = channelID
+
+	b.handleDisaster(context.Background(), client.disasters[1])
+	b.observe(now)
+
+	b.gapFill(context.Background())
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(channel.Messages))
+	}
+	if !b.isPosted("missed") {
+		t.Error("missed disaster not posted by gap fill")
+	}
+	if b.isPosted("before-gap") {
+		t.Error("disaster before the gap posted")
+	}
+	if got := b.lastSeen.Load(); got != now+30 {
+		t.Errorf("lastSeen = %d, want %d", got, now+30)
+	}
+}
+
+func TestBot_GapFill_CountsOnce(t *testing.T) {
+	now := time.Now().Unix()
+	client := &fakeClient{disasters: []*disastersv1.Disaster{
+		{Id: "filtered", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now - 60},
+	}}
+
+	b := newSubscriptionBot(t)
+	b.client = client
+
+	received := testutil.ToFloat64(metrics.DisastersReceived)
+	filtered := testutil.ToFloat64(metrics.DisastersFiltered.WithLabelValues(metrics.ReasonAlertLevel))
+
+	b.handleDisaster(context.Background(), client.disasters[0])
+	b.observe(now)
+	b.gapFill(context.Background())
+	b.gapFill(context.Background())
+
+	if got := testutil.ToFloat64(metrics.DisastersReceived) - received; got != 1 {
+		t.Errorf("received delta = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.DisastersFiltered.WithLabelValues(metrics.ReasonAlertLevel)) - filtered; got != 1 {
+		t.Errorf("filtered{alert_level} delta = %v, want 1", got)
+	}
+
+	// A changed payload is new again
+	client.disasters[0].AffectedPopulationCount = 1000
+	b.gapFill(context.Background())
+	if got := testutil.ToFloat64(metrics.DisastersReceived) - received; got != 2 {
+		t.Errorf("received delta after update = %v, want 2", got)
+	}
+}
+
+func TestStreamMatches(t *testing.T) {
+	flood := disastersv1.DisasterType_FLOOD
+	orange := disastersv1.AlertLevel_ORANGE
+	minMag := 5.0
+
+	tests := []struct {
+		name     string
+		req      *disastersv1.StreamDisastersRequest
+		disaster *disastersv1.Disaster
+		want     bool
+	}{
+		{"no filters", &disastersv1.StreamDisastersRequest{}, &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_GREEN}, true},
+		{"other type", &disastersv1.StreamDisastersRequest{Type: &flood}, &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE}, false},
+		{"below magnitude", &disastersv1.StreamDisastersRequest{MinMagnitude: &minMag}, &disastersv1.Disaster{Magnitude: 4.9}, false},
+		{"other level", &disastersv1.StreamDisastersRequest{AlertLevel: &orange}, &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_RED}, false},
+		{"below min level", &disastersv1.StreamDisastersRequest{MinAlertLevel: &orange}, &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_GREEN}, false},
+		{"at min level", &disastersv1.StreamDisastersRequest{MinAlertLevel: &orange}, &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_RED}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamMatches(tt.req, tt.disaster); got != tt.want {
+				t.Errorf("streamMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBot_GapFill_SameChannelsAsStream(t *testing.T) {
+	green := &disastersv1.Disaster{Id: "fl-green", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: time.Now().Unix()}
+
+	newBot := func(client *fakeClient) *Bot {
+		b := newSubscriptionBot(t)
+		b.session = newMockSession(t, "111111", "222222", "333333")
+		b.client = client
+		b.cfg().ChannelID = "111111"
+		b.cfg().Routes = []config.Route{{ChannelID: "333333", Rule: mustCompile(t, "alert_level == GREEN")}}
+		if err := b.subscriptions.Add(subscriptions.Subscription{ChannelID: "222222"}); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	postedTo := func(b *Bot) []string {
+		var channels []string
+		if rec := b.postedRecord(green.Id); rec != nil {
+			for _, m := range rec.Messages {
+				channels = append(channels, m.ChannelID)
+			}
+		}
+		slices.Sort(channels)
+		return channels
+	}
+
+	streamClient := &fakeClient{stream: make(chan *disastersv1.Disaster, 1)}
+	streamBot := newBot(streamClient)
+	streamClient.stream <- green
+	close(streamClient.stream)
+	if _, err := streamBot.streamDisasters(context.Background()); err == nil {
+		t.Fatal("streamDisasters() error = nil, want the end of the stream")
+	}
+
+	gapBot := newBot(&fakeClient{disasters: []*disastersv1.Disaster{green}})
+	gapBot.observe(green.Timestamp)
+	gapBot.gapFill(context.Background())
+
+	want := []string{"222222", "333333"}
+	if got := postedTo(streamBot); !slices.Equal(got, want) {
+		t.Fatalf("streamed to %v, want %v", got, want)
+	}
+	if got := postedTo(gapBot); !slices.Equal(got, want) {
+		t.Errorf("gap fill posted to %v, want %v like the stream", got, want)
+	}
+}
+
+func TestBot_GapFill_NothingSeen(t *testing.T) {
+	client := &fakeClient{}
+	b := &Bot{client: client}
+
+	b.gapFill(context.Background())
+
+	if client.listCalls != 0 {
+		t.Errorf("ListDisasters calls = %d, want none before anything was received", client.listCalls)
+	}
+}
+
+func TestBot_Backfill(t *testing.T) {
+	channelID := mockconstants.TestChannel
+	session := newMockSession(t, channelID)
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	red := disastersv1.AlertLevel_RED
+	client := &fakeClient{disasters: []*disastersv1.Disaster{
+		{Id: "too-old", Type: disastersv1.DisasterType_FLOOD, AlertLevel: red, Timestamp: base.Add(-time.Hour).Unix()},
+		{Id: "second", Type: disastersv1.DisasterType_FLOOD, AlertLevel: red, Timestamp: base.Add(2 * time.Hour).Unix()},
+		{Id: "first", Type: disastersv1.DisasterType_CYCLONE, AlertLevel: red, Timestamp: base.Add(time.Hour).Unix()},
+		{Id: "filtered", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: base.Add(time.Hour).Unix()},
+		{Id: "too-new", Type: disastersv1.DisasterType_FLOOD, AlertLevel: red, Timestamp: base.Add(48 * time.Hour).Unix()},
+	}}
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.client = client
+
+	posted, err := b.Backfill(context.Background(), base, base.Add(24*time.Hour), channelID)
+	if err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+	if posted != 2 {
+		t.Errorf("Backfill() = %d, want 2", posted)
+	}
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(channel.Messages))
+	}
+	if !strings.Contains(messageText(channel.Messages[0]), "CYCLONE") {
+		t.Errorf("first message = %q, want the oldest disaster first", messageText(channel.Messages[0]))
+	}
+
+	if b.isPosted("first") {
+		t.Error("backfill recorded a disaster in the store")
+	}
+	if acked := client.ackedIDs(); len(acked) != 0 {
+		t.Errorf("backfill acknowledged %v, want nothing", acked)
+	}
+}
+
+func TestBot_Backfill_TooManyToList(t *testing.T) {
+	channelID := mockconstants.TestChannel
+	session := newMockSession(t, channelID)
+
+	// The requested day is followed by more disasters than can be listed
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	client := &fakeClient{disasters: []*disastersv1.Disaster{
+		{Id: "requested", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, Timestamp: base.Add(time.Hour).Unix()},
+	}}
+	for i := range listMaxLimit {
+		client.disasters = append(client.disasters, &disastersv1.Disaster{Id: fmt.Sprintf("later-%d", i), Timestamp: base.Add(48*time.Hour).Unix() + int64(i)})
+	}
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.client = client
+
+	posted, err := b.Backfill(context.Background(), base, base.Add(24*time.Hour), channelID)
+	if !errors.Is(err, errListTruncated) {
+		t.Fatalf("Backfill() error = %v, want errListTruncated", err)
+	}
+	if posted != 0 {
+		t.Errorf("Backfill() = %d, want nothing posted", posted)
+	}
+	if channel, _ := session.State.Channel(channelID); len(channel.Messages) != 0 {
+		t.Errorf("expected no messages, got %d", len(channel.Messages))
+	}
+}
+
+func TestBot_HandleDisaster_DedupesAcrossUpstreams(t *testing.T) {
+	channelID := mockconstants.TestChannel
+	session := newMockSession(t, channelID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.cfg().ChannelID = channelID
+
+	// The same disaster seen on two upstreams after a failover, each with
+	// its own view of the source metadata
+	primary := &disastersv1.Disaster{Id: "fl-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, Source: "primary"}
+	backup := &disastersv1.Disaster{Id: "fl-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, Source: "backup"}
+
+	if sent := b.handleDisaster(context.Background(), primary); sent != 1 {
+		t.Fatalf("handleDisaster(primary) = %d, want 1", sent)
+	}
+	if sent := b.handleDisaster(context.Background(), backup); sent != 0 {
+		t.Errorf("handleDisaster(backup) = %d, want 0 for an already posted ID", sent)
+	}
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(channel.Messages))
+	}
+}
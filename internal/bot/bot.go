@@ -2,27 +2,57 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 
-	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/mr1hm/disaster-alerts-bot/internal/backoff"
 	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+	"github.com/mr1hm/disaster-alerts-bot/internal/geo"
+	"github.com/mr1hm/disaster-alerts-bot/internal/grpcclient"
+	"github.com/mr1hm/disaster-alerts-bot/internal/metrics"
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	"github.com/mr1hm/disaster-alerts-bot/internal/subscriptions"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
 )
 
 type Bot struct {
-	config  *config.Config
-	session *discordgo.Session
-	conn    *grpc.ClientConn
-	client  disastersv1.DisasterServiceClient
-	posted  map[string]bool
-	mu      sync.RWMutex
+	config        atomic.Pointer[config.Config] // Swapped by Reload
+	session       *discordgo.Session
+	upstreams     *grpcclient.Pool
+	client        disastersv1.DisasterServiceClient
+	subscriptions *subscriptions.Store
+	areas         map[string]*geo.Polygon
+	store         store.Store
+	mu            sync.Mutex
+
+	// outboxMu guards inflight and seen. It is only held briefly; lockDisaster
+	// serializes the deliveries of each disaster.
+	outboxMu   sync.Mutex
+	inflight   map[string]*disasterLock
+	seen       map[string]seenPayload // Payloads a gap fill could fetch again
+	outboxWake chan struct{}
+	ackWake    chan struct{}
+	ackPending atomic.Int64
+	lastSeen   atomic.Int64 // Latest disaster timestamp received, for gap fills
+	ackLinks   sync.Map     // Disaster ID to the span that delivered it, linked from its ack
+	stream     streamState
+	stopWork   context.CancelFunc
+	workers    sync.WaitGroup
+
+	restartPending map[string]config.Change // Restart-only changes Reload has logged
 }
 
 func New(cfg *config.Config) (*Bot, error) {
@@ -31,31 +61,171 @@ func New(cfg *config.Config) (*Bot, error) {
 		return nil, fmt.Errorf("creating discord session: %w", err)
 	}
 
-	conn, err := grpc.NewClient(cfg.GRPCAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	var areas map[string]*geo.Polygon
+	if cfg.GeofencesPath != "" {
+		areas, err = geo.LoadGeoJSON(cfg.GeofencesPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading geofences: %w", err)
+		}
+	}
+
+	subs, err := subscriptions.Load(cfg.SubscriptionsPath, areas)
+	if err != nil {
+		return nil, fmt.Errorf("loading subscriptions: %w", err)
+	}
+
+	st, err := openStore(cfg.StorePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening store: %w", err)
+	}
+
+	upstreams, err := dialUpstreams(cfg)
+	if err != nil {
+		st.Close()
+		return nil, err
+	}
+
+	b := &Bot{
+		session:       session,
+		upstreams:     upstreams,
+		client:        upstreams,
+		subscriptions: subs,
+		areas:         areas,
+		store:         st,
+		outboxWake:    make(chan struct{}, 1),
+		ackWake:       make(chan struct{}, 1),
+	}
+	b.config.Store(cfg)
+	return b, nil
+}
+
+// NewUpstream returns a Bot that is only connected to the upstream server,
+// with no Discord session, store or subscriptions. It can list disasters, as
+// TestRule does, but must not be started.
+func NewUpstream(cfg *config.Config) (*Bot, error) {
+	upstreams, err := dialUpstreams(cfg)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bot{upstreams: upstreams, client: upstreams}
+	b.config.Store(cfg)
+	return b, nil
+}
+
+// dialUpstreams creates the client pool for the configured upstream servers.
+func dialUpstreams(cfg *config.Config) (*grpcclient.Pool, error) {
+	opts, err := dialOptions(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("configuring grpc connection: %w", err)
+	}
+	upstreams, err := grpcclient.NewPool(cfg.GRPCAddresses, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("connecting to grpc server: %w", err)
 	}
+	return upstreams, nil
+}
 
-	return &Bot{
-		config:  cfg,
-		session: session,
-		conn:    conn,
-		client:  disastersv1.NewDisasterServiceClient(conn),
-		posted:  make(map[string]bool),
-	}, nil
+// cfg returns the current configuration. Callers reading several settings
+// that must agree should call it once.
+func (b *Bot) cfg() *config.Config {
+	return b.config.Load()
 }
 
-const (
-	maxRetries             = 5
-	minPopulationThreshold = 500000 // Alert if 500k+ people affected, even if green
-)
+// dialOptions returns the transport security, tracing, authentication and
+// keepalive options for the upstream connection.
+func dialOptions(cfg *config.Config) ([]grpc.DialOption, error) {
+	creds := insecure.NewCredentials()
+	if cfg.GRPCTLS {
+		var err error
+		creds, err = grpcclient.NewTLSCredentials(grpcclient.TLSOptions{
+			CAFile:     cfg.GRPCCAFile,
+			CertFile:   cfg.GRPCCertFile,
+			KeyFile:    cfg.GRPCKeyFile,
+			ServerName: cfg.GRPCServerName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("configuring tls: %w", err)
+		}
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+
+	if cfg.GRPCAuthToken != "" || cfg.GRPCAuthTokenFile != "" {
+		token, err := grpcclient.NewTokenCredentials(grpcclient.TokenOptions{
+			Token:         cfg.GRPCAuthToken,
+			File:          cfg.GRPCAuthTokenFile,
+			AllowInsecure: cfg.GRPCAuthAllowInsecure,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("configuring auth token: %w", err)
+		}
+		if !cfg.GRPCTLS && !cfg.GRPCAuthAllowInsecure {
+			return nil, errors.New("auth token requires GRPC_TLS or GRPC_AUTH_ALLOW_INSECURE")
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(token))
+	}
+
+	if cfg.GRPCKeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.GRPCKeepaliveTime,
+			Timeout:             cfg.GRPCKeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
+
+	return opts, nil
+}
+
+// openStore opens the posted-message store at path, or an in-memory store if
+// path is empty.
+func openStore(path string) (store.Store, error) {
+	if path == "" {
+		return store.NewMemory(), nil
+	}
+	return store.OpenBolt(path)
+}
+
+const storeRetention = 30 * 24 * time.Hour // Forget acknowledged disasters not seen for 30 days
+
+var errStreamIdle = errors.New("no disasters received within idle timeout")
 
 func (b *Bot) Start(ctx context.Context) error {
 	if err := b.session.Open(); err != nil {
 		return fmt.Errorf("opening discord connection: %w", err)
 	}
 
-	slog.Info("Bot started", "grpc_address", b.config.GRPCAddress)
+	slog.Info("Bot started", "grpc_addresses", b.cfg().GRPCAddresses)
+	b.stream.disconnected(false, time.Now()) // Not connected until runStream is
+
+	if err := b.registerCommands(); err != nil {
+		slog.Error("Failed to register slash commands", "error", err)
+		// Continue anyway - alerts don't depend on commands
+	}
+
+	b.pruneStore()
+
+	// Retry deliveries and acks left over from the last run, and any that
+	// fail from here on
+	workCtx, stopWork := context.WithCancel(ctx)
+	b.stopWork = stopWork
+	b.workers.Add(2)
+	go func() {
+		defer b.workers.Done()
+		b.runOutbox(workCtx)
+	}()
+	go func() {
+		defer b.workers.Done()
+		b.runAcks(workCtx)
+	}()
+	if b.upstreams != nil {
+		b.workers.Add(1)
+		go func() {
+			defer b.workers.Done()
+			b.upstreams.RunHealthChecks(workCtx, b.cfg().GRPCHealthCheckInterval)
+		}()
+	}
 
 	// Fetch and post existing disasters on startup
 	if err := b.fetchInitialDisasters(ctx); err != nil {
@@ -63,12 +233,33 @@ func (b *Bot) Start(ctx context.Context) error {
 		// Continue anyway - streaming will still work
 	}
 
+	return b.runStream(ctx)
+}
+
+// runStream keeps the disaster stream connected until ctx is done, backing
+// off between reconnect attempts. It gives up once ReconnectMaxRetries
+// consecutive attempts fail without receiving anything.
+func (b *Bot) runStream(ctx context.Context) error {
+	cfg := b.cfg()
+	policy := backoff.Policy{
+		Initial:    cfg.ReconnectInitialInterval,
+		Max:        cfg.ReconnectMaxInterval,
+		Multiplier: cfg.ReconnectMultiplier,
+		Jitter:     cfg.ReconnectJitter,
+	}
+	maxRetries := cfg.ReconnectMaxRetries
+
 	retries := 0
-	for {
+	for attempt := 0; ; attempt++ {
 		select {
 		case <-ctx.Done():
 			return nil
 		default:
+			if attempt > 0 {
+				// Catch up on what was emitted while disconnected
+				b.gapFill(ctx)
+			}
+
 			connected, err := b.streamDisasters(ctx)
 			if err != nil {
 				// Check if we're shutting down
@@ -79,136 +270,327 @@ func (b *Bot) Start(ctx context.Context) error {
 				if connected {
 					// Stream was working, reset retry count
 					retries = 0
-					slog.Info("Stream disconnected, reconnecting", "error", err)
+				}
+				retries++
+				if !connected && maxRetries > 0 && retries >= maxRetries {
+					return fmt.Errorf("stream failed after %d retries: %w", maxRetries, err)
+				}
+
+				delay := policy.Delay(retries)
+				if errors.Is(err, errStreamIdle) {
+					metrics.StreamReconnects.WithLabelValues("idle").Inc()
+					slog.Warn("Stream idle, reconnecting", "idle_timeout", cfg.StreamIdleTimeout, "delay", delay)
+				} else if connected {
+					metrics.StreamReconnects.WithLabelValues("disconnect").Inc()
+					slog.Info("Stream disconnected, reconnecting", "error", err, "delay", delay)
 				} else {
-					retries++
-					if retries >= maxRetries {
-						return fmt.Errorf("stream failed after %d retries: %w", maxRetries, err)
-					}
-					slog.Error("Stream error, reconnecting", "error", err, "retry", retries, "max_retries", maxRetries)
+					metrics.StreamReconnects.WithLabelValues("error").Inc()
+					slog.Error("Stream error, reconnecting", "error", err, "retry", retries, "max_retries", maxRetries, "delay", delay)
+				}
+				if backoff.Sleep(ctx, delay) != nil {
+					return nil
 				}
-				time.Sleep(5 * time.Second)
 			}
 		}
 	}
 }
 
 func (b *Bot) streamDisasters(ctx context.Context) (connected bool, err error) {
-	stream, err := b.client.StreamDisasters(ctx, &disastersv1.StreamDisastersRequest{})
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	stream, err := b.client.StreamDisasters(ctx, streamRequest())
 	if err != nil {
 		return false, fmt.Errorf("starting stream: %w", err)
 	}
 
-	slog.Info("Connected to disaster stream")
+	upstream := b.activeUpstream()
+	slog.Info("Connected to disaster stream", "upstream", upstream)
+	b.stream.connected(upstream, time.Now())
+
+	activity := make(chan struct{}, 1)
+	if timeout := b.cfg().StreamIdleTimeout; timeout > 0 {
+		go watchIdle(ctx, timeout, activity, cancel)
+	}
 
 	for {
 		disaster, err := stream.Recv()
 		if err != nil {
+			idle := errors.Is(context.Cause(ctx), errStreamIdle)
+			b.stream.disconnected(idle, time.Now())
+			if idle {
+				// The connection itself was up, so this isn't a failed attempt
+				return true, errStreamIdle
+			}
 			return connected, fmt.Errorf("receiving: %w", err)
 		}
 
 		connected = true // Successfully received at least one message
+		wake(activity)
+		b.stream.received(time.Now())
+		b.observe(disaster.Timestamp)
 
-		if !b.shouldPost(disaster) {
-			continue
+		if sent := b.handleDisaster(ctx, disaster); sent > 0 {
+			slog.Info("Posted disaster", "id", disaster.Id, "title", disaster.Title, "channels", sent)
 		}
+	}
+}
+
+// watchIdle cancels the stream with errStreamIdle if nothing arrives on
+// activity within timeout. Keepalive pings catch a dead connection; this
+// catches a stream that is connected but has silently stopped delivering.
+func watchIdle(ctx context.Context, timeout time.Duration, activity <-chan struct{}, cancel context.CancelCauseFunc) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 
-		if b.isPosted(disaster.Id) {
-			continue
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-activity:
+			timer.Reset(timeout)
+		case <-timer.C:
+			cancel(errStreamIdle)
+			return
 		}
+	}
+}
+
+// activeUpstream returns the address streams are opened against, or "" when
+// the bot has no upstream pool (as in tests).
+func (b *Bot) activeUpstream() string {
+	if b.upstreams == nil {
+		return ""
+	}
+	return b.upstreams.Active()
+}
 
-		if err := b.postDisaster(disaster); err != nil {
-			slog.Error("Failed to post disaster", "id", disaster.Id, "error", err)
-			continue
+// handleDisaster posts a disaster that hasn't been posted yet, or updates the
+// existing messages if it has. It returns how many messages were posted.
+//
+// New disasters go through the outbox: they are queued before posting, and
+// only recorded as posted and acknowledged once every route succeeded, so a
+// crash or a Discord outage never loses or double-posts a disaster.
+func (b *Bot) handleDisaster(ctx context.Context, d *disastersv1.Disaster) int {
+	ctx, span := tracer.Start(ctx, "disaster.handle", trace.WithAttributes(disasterAttributes(d)...))
+	defer span.End()
+
+	unlock := b.lockDisaster(d.Id)
+	defer unlock()
+
+	// Gap fills re-fetch the overlap window; count each payload only once
+	counted := b.firstSeen(d)
+	if counted {
+		metrics.DisastersReceived.Inc()
+	}
+
+	if prev := b.postedRecord(d.Id); prev != nil {
+		span.SetAttributes(attribute.String("disaster.outcome", "update"))
+		if !b.updateDisaster(prev, d) {
+			return 0
 		}
+		return b.postNewRoutes(ctx, prev, d)
+	}
 
-		b.acknowledgeDisaster(ctx, disaster.Id)
-		b.markPosted(disaster.Id)
-		slog.Info("Posted disaster", "id", disaster.Id, "title", disaster.Title)
+	if e, err := b.store.GetOutbox(d.Id); err == nil {
+		// Still waiting on a retry: deliver the latest values when it runs
+		if err := e.SetDisaster(d); err == nil {
+			err = b.store.PutOutbox(e)
+		}
+		if err != nil {
+			slog.Error("Failed to update queued disaster", "id", d.Id, "error", err)
+		}
+		span.SetAttributes(attribute.String("disaster.outcome", "queued"))
+		return 0
+	}
+
+	if len(b.filterRoutes(ctx, d)) == 0 {
+		reason := b.filterReason(d)
+		if reason == "" {
+			reason = metrics.ReasonNoChannel
+		}
+		if counted {
+			metrics.DisastersFiltered.WithLabelValues(reason).Inc()
+		}
+		span.SetAttributes(attribute.String("disaster.outcome", "filtered"))
+		return 0
 	}
+
+	span.SetAttributes(attribute.String("disaster.outcome", "posted"))
+	return b.enqueue(ctx, d, nil)
+}
+
+// filterRoutes returns the routes for d, tracing the filter decision.
+func (b *Bot) filterRoutes(ctx context.Context, d *disastersv1.Disaster) []route {
+	_, span := tracer.Start(ctx, "disaster.filter")
+	defer span.End()
+
+	routes := b.routes(d)
+	span.SetAttributes(attribute.Int("disaster.routes", len(routes)))
+	if reason := b.filterReason(d); reason != "" {
+		span.SetAttributes(attribute.String("disaster.filter_reason", reason))
+	}
+	return routes
 }
 
 func (b *Bot) shouldPost(d *disastersv1.Disaster) bool {
-	if d.Type == disastersv1.DisasterType_EARTHQUAKE {
-		// Require both magnitude AND population impact
-		return d.Magnitude >= b.config.MinMagnitude && d.AffectedPopulationCount >= minPopulationThreshold
+	return b.filterReason(d) == ""
+}
+
+// filterReason returns why the default channel filter rejects d, or "" if it
+// passes. With MatchAny the reason is the first condition checked.
+func (b *Bot) filterReason(d *disastersv1.Disaster) string {
+	th := b.cfg().Threshold(d.Type)
+
+	var checked int
+	var failed []string
+	check := func(pass bool, reason string) {
+		checked++
+		if !pass {
+			failed = append(failed, reason)
+		}
 	}
-	// Alert for orange/red OR high population impact
-	if d.AlertLevel >= b.config.AlertLevel {
-		return true
+	if th.AlertLevel != disastersv1.AlertLevel_UNKNOWN {
+		check(d.AlertLevel >= th.AlertLevel, metrics.ReasonAlertLevel)
 	}
-	return d.AffectedPopulationCount >= minPopulationThreshold
+	if th.MinMagnitude > 0 {
+		check(d.Magnitude >= th.MinMagnitude, metrics.ReasonMagnitude)
+	}
+	if th.MinPopulation > 0 {
+		check(d.AffectedPopulationCount >= th.MinPopulation, metrics.ReasonPopulation)
+	}
+
+	if len(failed) == 0 || (th.Match == config.MatchAny && len(failed) < checked) {
+		return ""
+	}
+	return failed[0]
 }
 
 func (b *Bot) Stop() {
+	if b.stopWork != nil {
+		b.stopWork()
+		b.workers.Wait()
+
+		// Send any acks still waiting for the next batch
+		ctx, cancel := context.WithTimeout(context.Background(), ackStopTimeout)
+		if err := b.flushAcks(ctx); err != nil {
+			slog.Error("Failed to flush acknowledgements", "error", err)
+		}
+		cancel()
+	}
 	if b.session != nil {
 		b.session.Close()
 	}
-	if b.conn != nil {
-		b.conn.Close()
+	if b.upstreams != nil {
+		b.upstreams.Close()
+	}
+	if b.store != nil {
+		if err := b.store.Close(); err != nil {
+			slog.Error("Failed to close store", "error", err)
+		}
 	}
 }
 
 func (b *Bot) fetchInitialDisasters(ctx context.Context) error {
 	discordSent := false
-	since := time.Now().Add(-24 * time.Hour).Unix()
+	now := time.Now()
+	since := now.Add(-b.cfg().BackfillWindow).Unix()
 
-	// Fetch unsent disasters from last 24h
-	resp, err := b.client.ListDisasters(ctx, &disastersv1.ListDisastersRequest{
-		Limit:       50,
-		Since:       &since,
-		DiscordSent: &discordSent,
-	})
-	if err != nil {
+	// Anything newer is caught by the stream, or by a gap fill if it drops
+	b.observe(now.Unix())
+
+	// Fetch unsent disasters from the backfill window
+	disasters, err := b.listDisastersSince(ctx, since, &discordSent)
+	if errors.Is(err, errListTruncated) {
+		slog.Warn("Too many unsent disasters, skipping the oldest", "window", b.cfg().BackfillWindow, "error", err)
+	} else if err != nil {
 		return fmt.Errorf("listing disasters: %w", err)
 	}
 
-	slog.Info("Fetched unsent disasters from last 24h", "count", len(resp.Disasters))
+	slog.Info("Fetched unsent disasters", "count", len(disasters), "window", b.cfg().BackfillWindow)
 
 	posted := 0
-	for _, disaster := range resp.Disasters {
-		if !b.shouldPost(disaster) {
-			continue
-		}
-
-		if err := b.postDisaster(disaster); err != nil {
-			slog.Error("Failed to post initial disaster", "id", disaster.Id, "error", err)
-			continue
+	for _, disaster := range streamed(disasters) {
+		if b.handleDisaster(ctx, disaster) > 0 {
+			posted++
 		}
-		b.acknowledgeDisaster(ctx, disaster.Id)
-		b.markPosted(disaster.Id)
-		posted++
 	}
 
 	slog.Info("Posted initial disasters", "count", posted)
 	return nil
 }
 
-func (b *Bot) acknowledgeDisaster(ctx context.Context, id string) {
-	_, err := b.client.AcknowledgeDisasters(ctx, &disastersv1.AcknowledgeDisastersRequest{
-		Ids: []string{id},
-	})
+func (b *Bot) pruneStore() {
+	pruned, err := b.store.Prune(time.Now().Add(-storeRetention))
 	if err != nil {
-		slog.Error("Failed to acknowledge disaster", "id", id, "error", err)
+		slog.Error("Failed to prune store", "error", err)
+		return
+	}
+	if pruned > 0 {
+		slog.Info("Pruned old disasters from store", "count", pruned)
 	}
 }
 
-func (b *Bot) postDisaster(d *disastersv1.Disaster) error {
-	msg := formatDisasterMessage(d)
-	_, err := b.session.ChannelMessageSend(b.config.ChannelID, msg)
-	return err
+func (b *Bot) postDisaster(ctx context.Context, r route, d *disastersv1.Disaster) (*discordgo.Message, error) {
+	_, span := tracer.Start(ctx, "disaster.post", trace.WithAttributes(
+		attribute.String("disaster.id", d.Id),
+		attribute.String("discord.channel_id", r.channelID),
+	))
+	defer span.End()
+
+	msg, err := b.session.ChannelMessageSendComplex(r.channelID, b.disasterMessage(d, r.distance()))
+	if err != nil {
+		spanError(span, err)
+	}
+	return msg, err
 }
 
 func (b *Bot) isPosted(id string) bool {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-	return b.posted[id]
+	return b.postedRecord(id) != nil
+}
+
+// postedRecord returns the stored record for a disaster, or nil if it hasn't
+// been posted.
+func (b *Bot) postedRecord(id string) *store.Record {
+	rec, err := b.store.Get(id)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			slog.Error("Failed to read store", "id", id, "error", err)
+		}
+		return nil
+	}
+	return rec
 }
 
-func (b *Bot) markPosted(id string) {
+// markPosted records d as posted with messages, removing it from the outbox.
+// If d was already posted, as when an update routes it to more channels, the
+// existing record keeps its state and only takes the new message list.
+func (b *Bot) markPosted(d *disastersv1.Disaster, messages []store.Message) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.posted[id] = true
+
+	if rec, err := b.store.Get(d.Id); err == nil {
+		rec.Messages = messages
+		rec.UpdatedAt = time.Now()
+		if err := b.store.Complete(rec); err != nil {
+			slog.Error("Failed to save disaster", "id", d.Id, "error", err)
+		}
+		return
+	}
+
+	err := b.store.Complete(&store.Record{
+		DisasterID:        d.Id,
+		Messages:          messages,
+		PayloadHash:       store.PayloadHash(d),
+		AlertLevel:        d.AlertLevel,
+		Population:        d.AffectedPopulationCount,
+		Magnitude:         d.Magnitude,
+		AlertedPopulation: d.AffectedPopulationCount,
+		UpdatedAt:         time.Now(),
+	})
+	if err != nil {
+		slog.Error("Failed to save disaster", "id", d.Id, "error", err)
+	}
 }
 
 func formatDisasterMessage(d *disastersv1.Disaster) string {
@@ -274,4 +656,3 @@ func formatAlertLevel(level disastersv1.AlertLevel) string {
 		return level.String()
 	}
 }
-
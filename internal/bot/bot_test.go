@@ -1,7 +1,16 @@
 package bot
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -14,29 +23,38 @@ import (
 	"github.com/ewohltman/discordgo-mock/mocksession"
 	"github.com/ewohltman/discordgo-mock/mockstate"
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+	"github.com/mr1hm/disaster-alerts-bot/internal/metrics"
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
 )
 
 func TestMain(m *testing.M) {
 	goleak.VerifyTestMain(m)
 }
 
-func newMockSession(t *testing.T, channelID string) *discordgo.Session {
+func newMockSession(t *testing.T, channelIDs ...string) *discordgo.Session {
 	t.Helper()
 
-	channel := mockchannel.New(
-		mockchannel.WithID(channelID),
-		mockchannel.WithGuildID(mockconstants.TestGuild),
-		mockchannel.WithName("disaster-alerts"),
-		mockchannel.WithType(discordgo.ChannelTypeGuildText),
-	)
+	var channels []*discordgo.Channel
+	for _, channelID := range channelIDs {
+		channels = append(channels, mockchannel.New(
+			mockchannel.WithID(channelID),
+			mockchannel.WithGuildID(mockconstants.TestGuild),
+			mockchannel.WithName("disaster-alerts"),
+			mockchannel.WithType(discordgo.ChannelTypeGuildText),
+		))
+	}
 
 	guild := mockguild.New(
 		mockguild.WithID(mockconstants.TestGuild),
 		mockguild.WithName("Test Server"),
-		mockguild.WithChannels(channel),
+		mockguild.WithChannels(channels...),
 	)
 
 	state, err := mockstate.New(mockstate.WithGuilds(guild))
@@ -46,7 +64,7 @@ func newMockSession(t *testing.T, channelID string) *discordgo.Session {
 
 	session, err := mocksession.New(
 		mocksession.WithState(state),
-		mocksession.WithClient(&http.Client{Transport: mockrest.NewTransport(state)}),
+		mocksession.WithClient(&http.Client{Transport: &mockTransport{state: state, next: mockrest.NewTransport(state)}}),
 	)
 	if err != nil {
 		t.Fatalf("failed to create mock session: %v", err)
@@ -55,18 +73,196 @@ func newMockSession(t *testing.T, channelID string) *discordgo.Session {
 	return session
 }
 
+// fakeClient is a DisasterServiceClient backed by an in-memory list of disasters.
+// Methods not overridden panic via the embedded nil interface.
+type fakeClient struct {
+	disastersv1.DisasterServiceClient
+	disasters []*disastersv1.Disaster
+	err       error
+
+	// stream feeds StreamDisasters; closing it ends the stream with io.EOF.
+	stream chan *disastersv1.Disaster
+
+	mu        sync.Mutex
+	acked     []string
+	ackCalls  int
+	listCalls int
+}
+
+func (f *fakeClient) GetDisaster(ctx context.Context, in *disastersv1.GetDisasterRequest, opts ...grpc.CallOption) (*disastersv1.Disaster, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, d := range f.disasters {
+		if d.Id == in.Id {
+			return d, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "disaster not found: %s", in.Id)
+}
+
+// ListDisasters mirrors the server: filters are applied, results are
+// newest-first and truncated to Limit.
+func (f *fakeClient) ListDisasters(ctx context.Context, in *disastersv1.ListDisastersRequest, opts ...grpc.CallOption) (*disastersv1.ListDisastersResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.listCalls++
+
+	var out []*disastersv1.Disaster
+	for _, d := range f.disasters {
+		if in.Since != nil && d.Timestamp < *in.Since {
+			continue
+		}
+		if in.Type != nil && d.Type != *in.Type {
+			continue
+		}
+		if in.MinAlertLevel != nil && d.AlertLevel < *in.MinAlertLevel {
+			continue
+		}
+		out = append(out, d)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp > out[j].Timestamp })
+	if in.Limit > 0 && len(out) > int(in.Limit) {
+		out = out[:in.Limit]
+	}
+	return &disastersv1.ListDisastersResponse{Disasters: out}, nil
+}
+
+func (f *fakeClient) StreamDisasters(ctx context.Context, in *disastersv1.StreamDisastersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[disastersv1.Disaster], error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &fakeStream{ctx: ctx, ch: f.stream, req: in}, nil
+}
+
+func (f *fakeClient) AcknowledgeDisasters(ctx context.Context, in *disastersv1.AcknowledgeDisastersRequest, opts ...grpc.CallOption) (*disastersv1.AcknowledgeDisastersResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = append(f.acked, in.Ids...)
+	f.ackCalls++
+	return &disastersv1.AcknowledgeDisastersResponse{AcknowledgedCount: int64(len(in.Ids))}, nil
+}
+
+func (f *fakeClient) ackedIDs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.acked)
+}
+
+// fakeStream delivers what is sent on ch that matches the request's filters,
+// like the server.
+type fakeStream struct {
+	grpc.ClientStream
+	ctx context.Context
+	ch  chan *disastersv1.Disaster
+	req *disastersv1.StreamDisastersRequest
+}
+
+func (s *fakeStream) Recv() (*disastersv1.Disaster, error) {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		case d, ok := <-s.ch:
+			if !ok {
+				return nil, io.EOF
+			}
+			if s.req == nil || streamMatches(s.req, d) {
+				return d, nil
+			}
+		}
+	}
+}
+
+// mockTransport adapts requests that the discordgo-mock REST transport can't
+// handle. Message sends are re-encoded as plain Messages, since the mock
+// rejects MessageSend-only fields like sticker_ids, and message edits are
+// applied to the state directly.
+type mockTransport struct {
+	state *discordgo.State
+	next  http.RoundTripper
+}
+
+var messagePath = regexp.MustCompile(`/channels/([^/]+)/messages(?:/([^/]+))?$`)
+
+func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	match := messagePath.FindStringSubmatch(req.URL.Path)
+	if match == nil || req.Body == nil {
+		return m.next.RoundTrip(req)
+	}
+
+	var msg discordgo.Message
+	if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+
+	switch {
+	case req.Method == http.MethodPost && match[2] == "":
+		body, err := json.Marshal(&msg)
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		return m.next.RoundTrip(req)
+	case req.Method == http.MethodPatch && match[2] != "":
+		existing, err := m.state.Message(match[1], match[2])
+		if err != nil {
+			return jsonResponse(http.StatusNotFound, map[string]string{"message": err.Error()})
+		}
+		msg.ID, msg.ChannelID = existing.ID, existing.ChannelID
+		if err := m.state.MessageAdd(&msg); err != nil {
+			return nil, err
+		}
+		return jsonResponse(http.StatusOK, existing)
+	default:
+		return m.next.RoundTrip(req)
+	}
+}
+
+// messageText flattens a message's content and embeds for assertions.
+func messageText(m *discordgo.Message) string {
+	parts := []string{m.Content}
+	for _, e := range m.Embeds {
+		parts = append(parts, e.Title, e.Description)
+		for _, f := range e.Fields {
+			parts = append(parts, f.Name+": "+f.Value)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func jsonResponse(status int, v any) (*http.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}, nil
+}
+
 func TestBot_PostDisaster(t *testing.T) {
 	channelID := mockconstants.TestChannel
 
 	session := newMockSession(t, channelID)
 
 	b := &Bot{
-		config: &config.Config{
-			ChannelID: channelID,
-		},
 		session: session,
-		posted:  make(map[string]bool),
+		store:   store.NewMemory(),
 	}
+	b.config.Store(&config.Config{
+		ChannelID: channelID,
+	})
 
 	disaster := &disastersv1.Disaster{
 		Id:                      "test-123",
@@ -81,7 +277,7 @@ func TestBot_PostDisaster(t *testing.T) {
 		Timestamp:               time.Date(2026, 1, 15, 14, 30, 0, 0, time.UTC).Unix(),
 	}
 
-	err := b.postDisaster(disaster)
+	_, err := b.postDisaster(context.Background(), route{channelID: channelID}, disaster)
 	if err != nil {
 		t.Fatalf("postDisaster() error = %v", err)
 	}
@@ -96,8 +292,54 @@ func TestBot_PostDisaster(t *testing.T) {
 		t.Errorf("expected 1 message, got %d", len(channel.Messages))
 	}
 
-	if channel.Messages[0].Content == "" {
-		t.Error("message content is empty")
+	if len(channel.Messages[0].Embeds) != 1 {
+		t.Fatalf("expected 1 embed, got %d", len(channel.Messages[0].Embeds))
+	}
+
+	embed := channel.Messages[0].Embeds[0]
+	if embed.Description != disaster.Title {
+		t.Errorf("embed description = %q, want %q", embed.Description, disaster.Title)
+	}
+	if embed.Color != colorOrange {
+		t.Errorf("embed color = %#x, want %#x", embed.Color, colorOrange)
+	}
+}
+
+func TestBot_PostDisaster_TextFormat(t *testing.T) {
+	channelID := mockconstants.TestChannel
+
+	session := newMockSession(t, channelID)
+
+	b := &Bot{
+		session: session,
+		store:   store.NewMemory(),
+	}
+	b.config.Store(&config.Config{
+		ChannelID:     channelID,
+		MessageFormat: config.MessageFormatText,
+	})
+
+	disaster := &disastersv1.Disaster{
+		Id:        "test-789",
+		Title:     "Flood in Bangladesh",
+		Type:      disastersv1.DisasterType_FLOOD,
+		Source:    "GDACS",
+		Timestamp: time.Now().Unix(),
+	}
+
+	if _, err := b.postDisaster(context.Background(), route{channelID: channelID}, disaster); err != nil {
+		t.Fatalf("postDisaster() error = %v", err)
+	}
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(channel.Messages))
+	}
+	if channel.Messages[0].Content != formatDisasterMessage(disaster) {
+		t.Errorf("message content = %q, want text format", channel.Messages[0].Content)
+	}
+	if len(channel.Messages[0].Embeds) != 0 {
+		t.Errorf("expected no embeds, got %d", len(channel.Messages[0].Embeds))
 	}
 }
 
@@ -107,12 +349,12 @@ func TestBot_PostDisaster_MarksAsPosted(t *testing.T) {
 	session := newMockSession(t, channelID)
 
 	b := &Bot{
-		config: &config.Config{
-			ChannelID: channelID,
-		},
 		session: session,
-		posted:  make(map[string]bool),
+		store:   store.NewMemory(),
 	}
+	b.config.Store(&config.Config{
+		ChannelID: channelID,
+	})
 
 	disaster := &disastersv1.Disaster{
 		Id:        "test-456",
@@ -124,10 +366,10 @@ func TestBot_PostDisaster_MarksAsPosted(t *testing.T) {
 	}
 
 	// Post and mark
-	if err := b.postDisaster(disaster); err != nil {
+	if _, err := b.postDisaster(context.Background(), route{channelID: channelID}, disaster); err != nil {
 		t.Fatalf("postDisaster() error = %v", err)
 	}
-	b.markPosted(disaster.Id)
+	b.markPosted(disaster, nil)
 
 	// Verify it's marked
 	if !b.isPosted(disaster.Id) {
@@ -135,7 +377,7 @@ func TestBot_PostDisaster_MarksAsPosted(t *testing.T) {
 	}
 
 	// Post again - should not add another message
-	if err := b.postDisaster(disaster); err != nil {
+	if _, err := b.postDisaster(context.Background(), route{channelID: channelID}, disaster); err != nil {
 		t.Fatalf("second postDisaster() error = %v", err)
 	}
 
@@ -149,14 +391,14 @@ func TestBot_PostDisaster_MarksAsPosted(t *testing.T) {
 
 func TestBot_PostedTracking(t *testing.T) {
 	b := &Bot{
-		posted: make(map[string]bool),
+		store: store.NewMemory(),
 	}
 
 	if b.isPosted("test-1") {
 		t.Error("isPosted(test-1) = true, want false")
 	}
 
-	b.markPosted("test-1")
+	b.markPosted(&disastersv1.Disaster{Id: "test-1"}, nil)
 
 	if !b.isPosted("test-1") {
 		t.Error("isPosted(test-1) = false, want true")
@@ -169,7 +411,7 @@ func TestBot_PostedTracking(t *testing.T) {
 
 func TestBot_PostedTracking_Concurrent(t *testing.T) {
 	b := &Bot{
-		posted: make(map[string]bool),
+		store: store.NewMemory(),
 	}
 
 	var wg sync.WaitGroup
@@ -180,7 +422,7 @@ func TestBot_PostedTracking_Concurrent(t *testing.T) {
 		wg.Add(1)
 		go func(id string) {
 			defer wg.Done()
-			b.markPosted(id)
+			b.markPosted(&disastersv1.Disaster{Id: id}, nil)
 		}(id)
 	}
 
@@ -247,12 +489,12 @@ func TestFormatDisasterMessage(t *testing.T) {
 }
 
 func TestBot_ShouldPost(t *testing.T) {
-	b := &Bot{
-		config: &config.Config{
-			MinMagnitude: 5.0,
-			AlertLevel:   disastersv1.AlertLevel_ORANGE,
-		},
-	}
+	b := &Bot{}
+	b.config.Store(&config.Config{
+		MinMagnitude:  5.0,
+		AlertLevel:    disastersv1.AlertLevel_ORANGE,
+		MinPopulation: 500000,
+	})
 
 	tests := []struct {
 		name     string
@@ -348,6 +590,199 @@ func TestBot_ShouldPost(t *testing.T) {
 	}
 }
 
+func TestBot_FilterReason(t *testing.T) {
+	b := &Bot{}
+	b.config.Store(&config.Config{
+		MinMagnitude:  5.0,
+		AlertLevel:    disastersv1.AlertLevel_ORANGE,
+		MinPopulation: 500000,
+	})
+
+	tests := []struct {
+		name     string
+		disaster *disastersv1.Disaster
+		want     string
+	}{
+		{"earthquake passes", &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 6, AffectedPopulationCount: 600000}, ""},
+		{"earthquake too weak", &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 4, AffectedPopulationCount: 600000}, metrics.ReasonMagnitude},
+		{"earthquake too few affected", &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 6, AffectedPopulationCount: 1000}, metrics.ReasonPopulation},
+		{"flood passes on alert level", &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED}, ""},
+		{"flood passes on population", &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 600000}, ""},
+		{"flood below alert level", &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN}, metrics.ReasonAlertLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.filterReason(tt.disaster); got != tt.want {
+				t.Errorf("filterReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// ALERT_LEVEL=UNKNOWN lets every non-earthquake through, whatever the
+// population, as it did before thresholds were configurable.
+func TestBot_ShouldPost_UnknownAlertLevel(t *testing.T) {
+	b := &Bot{}
+	b.config.Store(&config.Config{
+		MinMagnitude:  5.0,
+		AlertLevel:    disastersv1.AlertLevel_UNKNOWN,
+		MinPopulation: 500000,
+	})
+
+	tests := []struct {
+		name     string
+		disaster *disastersv1.Disaster
+		want     bool
+	}{
+		{"green flood with few affected", &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 10}, true},
+		{"flood without alert level", &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD}, true},
+		{"weak earthquake", &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_RED, Magnitude: 4.0, AffectedPopulationCount: 900000}, false},
+		{"strong earthquake with enough affected", &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 6.0, AffectedPopulationCount: 900000}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.shouldPost(tt.disaster); got != tt.want {
+				t.Errorf("shouldPost() = %v, want %v (reason %q)", got, tt.want, b.filterReason(tt.disaster))
+			}
+		})
+	}
+}
+
+func TestBot_ShouldPost_Thresholds(t *testing.T) {
+	b := &Bot{}
+	b.config.Store(&config.Config{
+		MinMagnitude:  5.0,
+		AlertLevel:    disastersv1.AlertLevel_ORANGE,
+		MinPopulation: 500000,
+		Thresholds: map[disastersv1.DisasterType]config.Threshold{
+			disastersv1.DisasterType_FLOOD: {
+				AlertLevel:    disastersv1.AlertLevel_RED,
+				MinPopulation: 1000000,
+				Match:         config.MatchAny,
+			},
+			disastersv1.DisasterType_EARTHQUAKE: {
+				AlertLevel:   disastersv1.AlertLevel_ORANGE,
+				MinMagnitude: 6.0,
+				Match:        config.MatchAll,
+			},
+			disastersv1.DisasterType_VOLCANO: {Match: config.MatchAll},
+			disastersv1.DisasterType_DROUGHT: {
+				AlertLevel:    disastersv1.AlertLevel_RED,
+				MinPopulation: 2000000,
+				Match:         config.MatchAll,
+			},
+		},
+	})
+
+	tests := []struct {
+		name       string
+		disaster   *disastersv1.Disaster
+		want       bool
+		wantReason string
+	}{
+		{
+			name:     "flood red with few affected",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED},
+			want:     true,
+		},
+		{
+			name:     "flood green with over a million affected",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 1500000},
+			want:     true,
+		},
+		{
+			name:       "flood orange passes the default but not its own threshold",
+			disaster:   &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 600000},
+			want:       false,
+			wantReason: metrics.ReasonAlertLevel,
+		},
+		{
+			name:     "earthquake orange and strong enough",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_ORANGE, Magnitude: 6.5},
+			want:     true,
+		},
+		{
+			name:       "earthquake strong but green",
+			disaster:   &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_GREEN, Magnitude: 7.0, AffectedPopulationCount: 900000},
+			want:       false,
+			wantReason: metrics.ReasonAlertLevel,
+		},
+		{
+			name:       "earthquake orange but too weak",
+			disaster:   &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_ORANGE, Magnitude: 5.5},
+			want:       false,
+			wantReason: metrics.ReasonMagnitude,
+		},
+		{
+			name:     "volcano without conditions",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_VOLCANO, AlertLevel: disastersv1.AlertLevel_GREEN},
+			want:     true,
+		},
+		{
+			name:       "drought red but too few affected",
+			disaster:   &disastersv1.Disaster{Type: disastersv1.DisasterType_DROUGHT, AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 1000000},
+			want:       false,
+			wantReason: metrics.ReasonPopulation,
+		},
+		{
+			name:     "drought red with enough affected",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_DROUGHT, AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 2000000},
+			want:     true,
+		},
+		{
+			name:     "cyclone falls back to the default",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 600000},
+			want:     true,
+		},
+		{
+			name:       "wildfire below the default",
+			disaster:   &disastersv1.Disaster{Type: disastersv1.DisasterType_WILDFIRE, AlertLevel: disastersv1.AlertLevel_GREEN},
+			want:       false,
+			wantReason: metrics.ReasonAlertLevel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.shouldPost(tt.disaster); got != tt.want {
+				t.Errorf("shouldPost() = %v, want %v", got, tt.want)
+			}
+			if got := b.filterReason(tt.disaster); got != tt.wantReason {
+				t.Errorf("filterReason() = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestBot_HandleDisaster_Metrics(t *testing.T) {
+	channelID := mockconstants.TestChannel
+	session := newMockSession(t, channelID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.cfg().ChannelID = channelID
+	b.cfg().AlertLevel = disastersv1.AlertLevel_ORANGE
+
+	received := testutil.ToFloat64(metrics.DisastersReceived)
+	filtered := testutil.ToFloat64(metrics.DisastersFiltered.WithLabelValues(metrics.ReasonAlertLevel))
+	posted := testutil.ToFloat64(metrics.MessagesPosted)
+
+	b.handleDisaster(context.Background(), &disastersv1.Disaster{Id: "fl-green", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN})
+	b.handleDisaster(context.Background(), &disastersv1.Disaster{Id: "fl-red", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, Timestamp: time.Now().Add(-time.Minute).Unix()})
+
+	if got := testutil.ToFloat64(metrics.DisastersReceived) - received; got != 2 {
+		t.Errorf("received delta = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.DisastersFiltered.WithLabelValues(metrics.ReasonAlertLevel)) - filtered; got != 1 {
+		t.Errorf("filtered{alert_level} delta = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.MessagesPosted) - posted; got != 1 {
+		t.Errorf("posted delta = %v, want 1", got)
+	}
+}
+
 func TestFormatAlertLevel(t *testing.T) {
 	tests := []struct {
 		level disastersv1.AlertLevel
@@ -374,3 +809,122 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestBot_RunStream_GivesUpAfterMaxRetries(t *testing.T) {
+	b := &Bot{
+		client: &fakeClient{err: status.Error(codes.Unavailable, "down")},
+	}
+	b.config.Store(&config.Config{
+		ReconnectInitialInterval: time.Millisecond,
+		ReconnectMaxInterval:     5 * time.Millisecond,
+		ReconnectMultiplier:      2,
+		ReconnectMaxRetries:      3,
+	})
+
+	err := b.runStream(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "after 3 retries") {
+		t.Errorf("runStream() error = %v, want failure after 3 retries", err)
+	}
+}
+
+func TestBot_RunStream_CancelDuringBackoff(t *testing.T) {
+	b := &Bot{
+		client: &fakeClient{err: status.Error(codes.Unavailable, "down")},
+	}
+	b.config.Store(&config.Config{
+		ReconnectInitialInterval: time.Hour,
+		ReconnectMaxInterval:     time.Hour,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- b.runStream(ctx) }()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("runStream() error = %v, want nil on shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runStream did not return after ctx was cancelled")
+	}
+}
+
+func TestBot_StreamDisasters_IdleTimeout(t *testing.T) {
+	stream := make(chan *disastersv1.Disaster, 1)
+	b := newSubscriptionBot(t)
+	b.client = &fakeClient{stream: stream}
+	b.cfg().StreamIdleTimeout = 50 * time.Millisecond
+
+	stream <- &disastersv1.Disaster{Id: "eq-1", Type: disastersv1.DisasterType_EARTHQUAKE}
+
+	connected, err := b.streamDisasters(context.Background())
+	if !errors.Is(err, errStreamIdle) {
+		t.Fatalf("streamDisasters() error = %v, want errStreamIdle", err)
+	}
+	if !connected {
+		t.Error("streamDisasters() connected = false, want true")
+	}
+
+	st := b.StreamStatus()
+	if st.Connected || st.IdleTimeouts != 1 {
+		t.Errorf("StreamStatus() = %+v, want disconnected after one idle timeout", st)
+	}
+	if st.LastMessage.IsZero() || st.ConnectedAt.IsZero() {
+		t.Errorf("StreamStatus() = %+v, want connection and message times", st)
+	}
+}
+
+func TestBot_RunStream_IdleTimeoutsDontCountAsRetries(t *testing.T) {
+	b := &Bot{
+		client: &fakeClient{},
+	}
+	b.config.Store(&config.Config{
+		ReconnectInitialInterval: time.Millisecond,
+		ReconnectMaxInterval:     time.Millisecond,
+		ReconnectMaxRetries:      1,
+		StreamIdleTimeout:        10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := b.runStream(ctx); err != nil {
+		t.Errorf("runStream() error = %v, want nil once ctx is done", err)
+	}
+	if got := b.StreamStatus().IdleTimeouts; got < 2 {
+		t.Errorf("IdleTimeouts = %d, want the stream recycled repeatedly", got)
+	}
+}
+
+func TestDialOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		want    int
+		wantErr bool
+	}{
+		{"plaintext", config.Config{}, 2, false},
+		{"token over plaintext", config.Config{GRPCAuthToken: "secret"}, 0, true},
+		{"token over plaintext allowed", config.Config{GRPCAuthToken: "secret", GRPCAuthAllowInsecure: true}, 3, false},
+		{"token over tls", config.Config{GRPCTLS: true, GRPCAuthToken: "secret"}, 3, false},
+		{"keepalive", config.Config{GRPCKeepaliveTime: time.Minute, GRPCKeepaliveTimeout: time.Second}, 3, false},
+		{"missing token file", config.Config{GRPCTLS: true, GRPCAuthTokenFile: "/nonexistent/token"}, 0, true},
+		{"missing ca file", config.Config{GRPCTLS: true, GRPCCAFile: "/nonexistent/ca.pem"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := dialOptions(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dialOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(opts) != tt.want {
+				t.Errorf("dialOptions() returned %d options, want %d", len(opts), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+const commandTimeout = 10 * time.Second
+
+var getCommand = &discordgo.ApplicationCommand{
+	Name:        "get",
+	Description: "Fetch details for a disaster by ID",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "id",
+			Description: "Disaster ID",
+			Required:    true,
+		},
+	},
+}
+
+var commands = []*discordgo.ApplicationCommand{
+	getCommand,
+	recentCommand,
+	subscribeCommand,
+	unsubscribeCommand,
+}
+
+// registerCommands installs the interaction handler and registers the slash
+// commands with Discord. Must be called after the session is open so the
+// application ID is known.
+func (b *Bot) registerCommands() error {
+	b.session.AddHandler(b.handleInteraction)
+
+	if _, err := b.session.ApplicationCommandBulkOverwrite(b.session.State.User.ID, b.cfg().GuildID, commands); err != nil {
+		return fmt.Errorf("registering commands: %w", err)
+	}
+
+	slog.Info("Registered slash commands", "count", len(commands), "guild_id", b.cfg().GuildID)
+	return nil
+}
+
+func (b *Bot) handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.handleCommand(ctx, s, i)
+	case discordgo.InteractionMessageComponent:
+		b.handleComponent(ctx, s, i)
+	}
+}
+
+func (b *Bot) handleCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	var flags discordgo.MessageFlags
+	if b.cfg().EphemeralReplies {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+
+	respond := func(resp *discordgo.InteractionResponseData) error {
+		resp.Flags |= flags
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: resp,
+		})
+	}
+	// Commands calling upstream are acknowledged first and answered by editing
+	// the reply, since the call can outlast Discord's deadline
+	deferred := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: flags},
+	}
+
+	var err error
+	switch data.Name {
+	case getCommand.Name:
+		err = respondDeferred(ctx, s, i, deferred, func(ctx context.Context) *discordgo.InteractionResponseData {
+			return b.getCommandResponse(ctx, optionString(data.Options, "id"))
+		})
+	case recentCommand.Name:
+		err = respondDeferred(ctx, s, i, deferred, func(ctx context.Context) *discordgo.InteractionResponseData {
+			return b.recentResponse(ctx, recentQueryFromOptions(data.Options, time.Now()), 0)
+		})
+	case subscribeCommand.Name, unsubscribeCommand.Name:
+		switch {
+		case i.GuildID == "":
+			// Hidden in DMs, but a stale client may still send them
+			err = respond(&discordgo.InteractionResponseData{Content: "Subscriptions can only be managed in a server channel."})
+		case data.Name == subscribeCommand.Name:
+			err = respond(b.subscribeResponse(i.ChannelID, i.GuildID, data.Options))
+		default:
+			err = respond(b.unsubscribeResponse(i.ChannelID, data.Options))
+		}
+	default:
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to respond to command", "command", data.Name, "error", err)
+	}
+}
+
+// handleComponent handles button presses on messages previously sent by a
+// command, replacing the message in place.
+func (b *Bot) handleComponent(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+
+	var build func(context.Context) *discordgo.InteractionResponseData
+	switch {
+	case strings.HasPrefix(customID, recentCustomID+":"):
+		q, page, err := parseRecentCustomID(customID)
+		if err != nil {
+			slog.Error("Failed to parse component", "custom_id", customID, "error", err)
+			return
+		}
+		build = func(ctx context.Context) *discordgo.InteractionResponseData {
+			return b.recentResponse(ctx, q, page)
+		}
+	default:
+		return
+	}
+
+	deferred := &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}
+	if err := respondDeferred(ctx, s, i, deferred, build); err != nil {
+		slog.Error("Failed to respond to component", "custom_id", customID, "error", err)
+	}
+}
+
+// respondDeferred acknowledges an interaction with deferred, then replaces the
+// reply, or the message a component is on, with the response from build.
+// Discord drops interactions not acknowledged within 3 seconds, which an
+// upstream call can take longer than.
+func respondDeferred(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, deferred *discordgo.InteractionResponse, build func(context.Context) *discordgo.InteractionResponseData) error {
+	if err := s.InteractionRespond(i.Interaction, deferred); err != nil {
+		return fmt.Errorf("acknowledging interaction: %w", err)
+	}
+
+	resp := build(ctx)
+	// Empty lists, unlike omitted ones, clear what the message had before
+	embeds, components := resp.Embeds, resp.Components
+	if embeds == nil {
+		embeds = []*discordgo.MessageEmbed{}
+	}
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &resp.Content,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	return err
+}
+
+func (b *Bot) getCommandResponse(ctx context.Context, id string) *discordgo.InteractionResponseData {
+	if id == "" {
+		return &discordgo.InteractionResponseData{Content: "Please provide a disaster ID."}
+	}
+
+	disaster, err := b.client.GetDisaster(ctx, &disastersv1.GetDisasterRequest{Id: id})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &discordgo.InteractionResponseData{Content: fmt.Sprintf("No disaster found with ID `%s`.", id)}
+		}
+		slog.Error("Failed to get disaster", "id", id, "error", err)
+		return &discordgo.InteractionResponseData{Content: "Failed to fetch disaster details, please try again later."}
+	}
+
+	msg := b.disasterMessage(disaster, "")
+	return &discordgo.InteractionResponseData{Content: msg.Content, Embeds: msg.Embeds}
+}
+
+func optionString(opts []*discordgo.ApplicationCommandInteractionDataOption, name string) string {
+	for _, opt := range opts {
+		if opt.Name == name {
+			return opt.StringValue()
+		}
+	}
+	return ""
+}
+
+func optionInt(opts []*discordgo.ApplicationCommandInteractionDataOption, name string) int64 {
+	for _, opt := range opts {
+		if opt.Name == name {
+			return opt.IntValue()
+		}
+	}
+	return 0
+}
+
+func optionFloat(opts []*discordgo.ApplicationCommandInteractionDataOption, name string) (float64, bool) {
+	for _, opt := range opts {
+		if opt.Name == name {
+			return opt.FloatValue(), true
+		}
+	}
+	return 0, false
+}
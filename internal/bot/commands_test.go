@@ -0,0 +1,234 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+)
+
+func TestBot_GetCommandResponse(t *testing.T) {
+	client := &fakeClient{
+		disasters: []*disastersv1.Disaster{
+			{
+				Id:         "eq-1",
+				Title:      "Red earthquake alert in Indonesia",
+				Type:       disastersv1.DisasterType_EARTHQUAKE,
+				Magnitude:  7.2,
+				AlertLevel: disastersv1.AlertLevel_RED,
+				Source:     "GDACS",
+			},
+		},
+	}
+
+	b := &Bot{
+		client: client,
+	}
+	b.config.Store(&config.Config{MessageFormat: config.MessageFormatText})
+
+	tests := []struct {
+		name string
+		id   string
+		err  error
+		want string
+	}{
+		{"found", "eq-1", nil, "Red earthquake alert in Indonesia"},
+		{"not found", "missing", nil, "No disaster found with ID `missing`"},
+		{"empty id", "", nil, "Please provide a disaster ID"},
+		{"upstream error", "eq-1", status.Error(codes.Unavailable, "down"), "Failed to fetch disaster details"},
+		{"non-grpc error", "eq-1", errors.New("boom"), "Failed to fetch disaster details"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.err = tt.err
+			resp := b.getCommandResponse(context.Background(), tt.id)
+			if !strings.Contains(resp.Content, tt.want) {
+				t.Errorf("getCommandResponse(%q) = %q, want to contain %q", tt.id, resp.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestBot_GetCommandResponse_Embed(t *testing.T) {
+	b := &Bot{
+		client: &fakeClient{disasters: []*disastersv1.Disaster{{Id: "eq-1", Title: "Earthquake"}}},
+	}
+	b.config.Store(&config.Config{MessageFormat: config.MessageFormatEmbed})
+
+	resp := b.getCommandResponse(context.Background(), "eq-1")
+	if len(resp.Embeds) != 1 || resp.Embeds[0].Description != "Earthquake" {
+		t.Errorf("getCommandResponse() embeds = %+v, want one embed for eq-1", resp.Embeds)
+	}
+}
+
+func TestOptionString(t *testing.T) {
+	opts := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "id", Type: discordgo.ApplicationCommandOptionString, Value: "eq-1"},
+	}
+
+	if got := optionString(opts, "id"); got != "eq-1" {
+		t.Errorf("optionString(id) = %q, want %q", got, "eq-1")
+	}
+	if got := optionString(opts, "other"); got != "" {
+		t.Errorf("optionString(other) = %q, want empty", got)
+	}
+}
+
+// interactionRecorder is a Discord REST transport that records interaction
+// responses and reply edits, in order with the upstream calls made by
+// recordingClient.
+type interactionRecorder struct {
+	mu     sync.Mutex
+	events []string
+	bodies []string
+}
+
+func (r *interactionRecorder) record(event, body string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	r.bodies = append(r.bodies, body)
+}
+
+func (r *interactionRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/callback"):
+		var resp discordgo.InteractionResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		r.record(fmt.Sprintf("respond:%d", resp.Type), string(body))
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+	case req.Method == http.MethodPatch && strings.HasSuffix(req.URL.Path, "/messages/@original"):
+		r.record("edit", string(body))
+		return jsonResponse(http.StatusOK, map[string]string{"id": "1"})
+	}
+	return jsonResponse(http.StatusNotFound, map[string]string{"message": "unexpected request " + req.URL.Path})
+}
+
+type recordingClient struct {
+	*fakeClient
+	rec *interactionRecorder
+}
+
+func (c recordingClient) GetDisaster(ctx context.Context, in *disastersv1.GetDisasterRequest, opts ...grpc.CallOption) (*disastersv1.Disaster, error) {
+	c.rec.record("upstream", in.Id)
+	return c.fakeClient.GetDisaster(ctx, in, opts...)
+}
+
+func (c recordingClient) ListDisasters(ctx context.Context, in *disastersv1.ListDisastersRequest, opts ...grpc.CallOption) (*disastersv1.ListDisastersResponse, error) {
+	c.rec.record("upstream", "")
+	return c.fakeClient.ListDisasters(ctx, in, opts...)
+}
+
+func TestBot_HandleInteraction_DefersUpstreamCalls(t *testing.T) {
+	now := time.Now()
+	client := &fakeClient{disasters: []*disastersv1.Disaster{
+		{Id: "eq-1", Title: "Red earthquake alert in Indonesia", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_RED, Timestamp: now.Unix()},
+	}}
+	pageID := recentQuery{Since: now.Add(-time.Hour).Unix(), Limit: 25}.customID(0)
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.Interaction
+		upstreamErr error
+		wantEvents  []string
+		wantEdit    string
+	}{
+		{
+			name: "get",
+			interaction: &discordgo.Interaction{Type: discordgo.InteractionApplicationCommand, Data: discordgo.ApplicationCommandInteractionData{
+				Name:    "get",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "id", Type: discordgo.ApplicationCommandOptionString, Value: "eq-1"}},
+			}},
+			wantEvents: []string{"respond:5", "upstream", "edit"},
+			wantEdit:   "Red earthquake alert in Indonesia",
+		},
+		{
+			name: "get upstream error",
+			interaction: &discordgo.Interaction{Type: discordgo.InteractionApplicationCommand, Data: discordgo.ApplicationCommandInteractionData{
+				Name:    "get",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "id", Type: discordgo.ApplicationCommandOptionString, Value: "eq-1"}},
+			}},
+			upstreamErr: status.Error(codes.Unavailable, "down"),
+			wantEvents:  []string{"respond:5", "upstream", "edit"},
+			wantEdit:    "Failed to fetch disaster details",
+		},
+		{
+			name:        "recent",
+			interaction: &discordgo.Interaction{Type: discordgo.InteractionApplicationCommand, Data: discordgo.ApplicationCommandInteractionData{Name: "recent"}},
+			wantEvents:  []string{"respond:5", "upstream", "edit"},
+			wantEdit:    "Red earthquake alert in Indonesia",
+		},
+		{
+			name:        "recent page",
+			interaction: &discordgo.Interaction{Type: discordgo.InteractionMessageComponent, Data: discordgo.MessageComponentInteractionData{CustomID: pageID}},
+			wantEvents:  []string{"respond:6", "upstream", "edit"},
+			wantEdit:    "Red earthquake alert in Indonesia",
+		},
+		{
+			name:        "unsubscribe answers directly",
+			interaction: &discordgo.Interaction{Type: discordgo.InteractionApplicationCommand, GuildID: "456", Data: discordgo.ApplicationCommandInteractionData{Name: "unsubscribe"}},
+			wantEvents:  []string{"respond:4"},
+			wantEdit:    "No matching subscription",
+		},
+		{
+			name:        "subscribe outside a server",
+			interaction: &discordgo.Interaction{Type: discordgo.InteractionApplicationCommand, Data: discordgo.ApplicationCommandInteractionData{Name: "subscribe"}},
+			wantEvents:  []string{"respond:4"},
+			wantEdit:    "only be managed in a server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &interactionRecorder{}
+			session, err := discordgo.New("Bot test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			session.Client = &http.Client{Transport: rec}
+
+			client.err = tt.upstreamErr
+			b := newSubscriptionBot(t)
+			b.client = recordingClient{fakeClient: client, rec: rec}
+			b.cfg().MessageFormat = config.MessageFormatText
+			b.cfg().EphemeralReplies = true
+
+			tt.interaction.ID, tt.interaction.AppID, tt.interaction.Token = "1", "2", "token"
+			tt.interaction.ChannelID = "123"
+			b.handleInteraction(session, &discordgo.InteractionCreate{Interaction: tt.interaction})
+
+			if !slices.Equal(rec.events, tt.wantEvents) {
+				t.Fatalf("events = %v, want %v", rec.events, tt.wantEvents)
+			}
+			if tt.interaction.Type == discordgo.InteractionApplicationCommand && !strings.Contains(rec.bodies[0], `"flags":64`) {
+				t.Errorf("first response = %s, want it ephemeral", rec.bodies[0])
+			}
+			if tt.wantEdit != "" && !strings.Contains(rec.bodies[len(rec.bodies)-1], tt.wantEdit) {
+				t.Errorf("edit = %s, want to contain %q", rec.bodies[len(rec.bodies)-1], tt.wantEdit)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+const (
+	colorGreen   = 0x2ecc71
+	colorOrange  = 0xe67e22
+	colorRed     = 0xe74c3c
+	colorUnknown = 0x95a5a6
+)
+
+// disasterMessage renders d in the configured message format. distance is
+// the rendered geofence distance of the route it is posted through, if any.
+func (b *Bot) disasterMessage(d *disastersv1.Disaster, distance string) *discordgo.MessageSend {
+	if b.cfg().MessageFormat == config.MessageFormatText {
+		msg := formatDisasterMessage(d)
+		if distance != "" {
+			msg += fmt.Sprintf("\n**DISTANCE:** %s", distance)
+		}
+		return &discordgo.MessageSend{Content: msg}
+	}
+
+	embed := formatDisasterEmbed(d)
+	if distance != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Distance",
+			Value: distance,
+		})
+	}
+	return &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}}
+}
+
+// formatDisasterEmbed renders the same details as formatDisasterMessage as a
+// Discord embed colored by alert level.
+func formatDisasterEmbed(d *disastersv1.Disaster) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s", getAlertEmoji(d.AlertLevel), d.Type.String()),
+		Description: d.Title,
+		URL:         d.ReportUrl,
+		Color:       getAlertColor(d.AlertLevel),
+		Footer:      &discordgo.MessageEmbedFooter{Text: "ID: " + d.Id},
+	}
+	if d.Timestamp != 0 {
+		embed.Timestamp = time.Unix(d.Timestamp, 0).UTC().Format(time.RFC3339)
+	}
+
+	if d.AffectedPopulation != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Affected",
+			Value: d.AffectedPopulation,
+		})
+	}
+
+	if d.Type == disastersv1.DisasterType_EARTHQUAKE {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Magnitude",
+			Value:  fmt.Sprintf("%.1f", d.Magnitude),
+			Inline: true,
+		})
+	}
+
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:   "Location",
+		Value:  fmt.Sprintf("%.4f° N, %.4f° E", d.Latitude, d.Longitude),
+		Inline: true,
+	})
+
+	// Discord rejects embeds with an empty field value
+	if d.Source != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Source",
+			Value:  d.Source,
+			Inline: true,
+		})
+	}
+
+	if d.AlertLevel != disastersv1.AlertLevel_UNKNOWN {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Alert",
+			Value: formatAlertLevel(d.AlertLevel),
+		})
+	}
+
+	return embed
+}
+
+func getAlertColor(level disastersv1.AlertLevel) int {
+	switch level {
+	case disastersv1.AlertLevel_GREEN:
+		return colorGreen
+	case disastersv1.AlertLevel_ORANGE:
+		return colorOrange
+	case disastersv1.AlertLevel_RED:
+		return colorRed
+	default:
+		return colorUnknown
+	}
+}
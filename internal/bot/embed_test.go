@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+func TestFormatDisasterEmbed(t *testing.T) {
+	disaster := &disastersv1.Disaster{
+		Id:                 "test-123",
+		Title:              "M 6.5 - Near Tokyo, Japan",
+		Type:               disastersv1.DisasterType_EARTHQUAKE,
+		Magnitude:          6.5,
+		Latitude:           35.6762,
+		Longitude:          139.6503,
+		AlertLevel:         disastersv1.AlertLevel_RED,
+		Source:             "GDACS",
+		Timestamp:          time.Date(2026, 1, 15, 14, 30, 0, 0, time.UTC).Unix(),
+		AffectedPopulation: "1.2 million in affected area",
+		ReportUrl:          "https://example.com/report/123",
+	}
+
+	embed := formatDisasterEmbed(disaster)
+
+	if embed.Title != "🔴 EARTHQUAKE" {
+		t.Errorf("Title = %q, want %q", embed.Title, "🔴 EARTHQUAKE")
+	}
+	if embed.URL != disaster.ReportUrl {
+		t.Errorf("URL = %q, want %q", embed.URL, disaster.ReportUrl)
+	}
+	if embed.Color != colorRed {
+		t.Errorf("Color = %#x, want %#x", embed.Color, colorRed)
+	}
+	if embed.Timestamp != "2026-01-15T14:30:00Z" {
+		t.Errorf("Timestamp = %q, want %q", embed.Timestamp, "2026-01-15T14:30:00Z")
+	}
+
+	fields := make(map[string]string)
+	for _, f := range embed.Fields {
+		fields[f.Name] = f.Value
+	}
+
+	checks := map[string]string{
+		"Affected":  "1.2 million in affected area",
+		"Magnitude": "6.5",
+		"Location":  "35.6762° N, 139.6503° E",
+		"Source":    "GDACS",
+		"Alert":     "🔴 Severe impact, likely needs international humanitarian aid",
+	}
+	for name, want := range checks {
+		if fields[name] != want {
+			t.Errorf("field %s = %q, want %q", name, fields[name], want)
+		}
+	}
+}
+
+func TestFormatDisasterEmbed_OmitsEmptyFields(t *testing.T) {
+	embed := formatDisasterEmbed(&disastersv1.Disaster{
+		Type:   disastersv1.DisasterType_FLOOD,
+		Source: "GDACS",
+	})
+
+	for _, f := range embed.Fields {
+		switch f.Name {
+		case "Affected", "Magnitude", "Alert":
+			t.Errorf("unexpected field %s for flood without population or alert level", f.Name)
+		}
+	}
+	if embed.Timestamp != "" {
+		t.Errorf("Timestamp = %q, want empty", embed.Timestamp)
+	}
+}
+
+func TestFormatDisasterEmbed_NoEmptyFieldValues(t *testing.T) {
+	embed := formatDisasterEmbed(&disastersv1.Disaster{Type: disastersv1.DisasterType_CYCLONE})
+
+	for _, f := range embed.Fields {
+		if f.Value == "" {
+			t.Errorf("field %s has an empty value", f.Name)
+		}
+	}
+}
+
+func TestGetAlertColor(t *testing.T) {
+	tests := []struct {
+		level disastersv1.AlertLevel
+		want  int
+	}{
+		{disastersv1.AlertLevel_GREEN, colorGreen},
+		{disastersv1.AlertLevel_ORANGE, colorOrange},
+		{disastersv1.AlertLevel_RED, colorRed},
+		{disastersv1.AlertLevel_UNKNOWN, colorUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := getAlertColor(tt.level); got != tt.want {
+			t.Errorf("getAlertColor(%v) = %#x, want %#x", tt.level, got, tt.want)
+		}
+	}
+}
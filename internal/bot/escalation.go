@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// escalationRole returns the role to ping when a disaster of type t escalates,
+// or "" if escalation alerts are disabled for t.
+func (b *Bot) escalationRole(t disastersv1.DisasterType) string {
+	roles := b.cfg().EscalationRoles
+	if role, ok := roles[t]; ok {
+		return role
+	}
+	return roles[disastersv1.DisasterType_UNSPECIFIED]
+}
+
+// escalation describes why d warrants a fresh alert compared to what was last
+// alerted, or returns "" if it doesn't. A disaster escalates when it crosses
+// into RED or its affected population reaches its type's configured multiple
+// of the population at the last alert.
+func (b *Bot) escalation(prev *store.Record, d *disastersv1.Disaster) string {
+	if prev.AlertLevel < disastersv1.AlertLevel_RED && d.AlertLevel == disastersv1.AlertLevel_RED {
+		return fmt.Sprintf("%s → %s", prev.AlertLevel, d.AlertLevel)
+	}
+
+	mult := b.cfg().PopulationMultiple(d.Type)
+	if mult > 0 && prev.AlertedPopulation > 0 &&
+		float64(d.AffectedPopulationCount) >= float64(prev.AlertedPopulation)*mult {
+		return fmt.Sprintf("affected population %d → %d", prev.AlertedPopulation, d.AffectedPopulationCount)
+	}
+
+	return ""
+}
+
+// escalate posts a fresh alert for d that pings roleID, replying to each of
+// the original messages so the thread of updates stays together. It returns
+// how many alerts were sent.
+func (b *Bot) escalate(d *disastersv1.Disaster, messages []store.Message, roleID, reason string) int {
+	failIfNotExists := false
+	sent := 0
+	for _, m := range messages {
+		msg := b.disasterMessage(d, m.Distance)
+
+		header := fmt.Sprintf("<@&%s> **ESCALATED:** %s", roleID, reason)
+		if msg.Content != "" {
+			msg.Content = header + "\n" + msg.Content
+		} else {
+			msg.Content = header
+		}
+		msg.AllowedMentions = &discordgo.MessageAllowedMentions{Roles: []string{roleID}}
+		msg.Reference = &discordgo.MessageReference{
+			MessageID:       m.MessageID,
+			ChannelID:       m.ChannelID,
+			FailIfNotExists: &failIfNotExists,
+		}
+
+		if _, err := b.session.ChannelMessageSendComplex(m.ChannelID, msg); err != nil {
+			slog.Error("Failed to post escalation", "id", d.Id, "channel_id", m.ChannelID, "error", err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+)
+
+func TestBot_EscalationRole(t *testing.T) {
+	b := newSubscriptionBot(t)
+	b.cfg().EscalationRoles = map[disastersv1.DisasterType]string{
+		disastersv1.DisasterType_EARTHQUAKE:  "quake-role",
+		disastersv1.DisasterType_UNSPECIFIED: "default-role",
+	}
+
+	if got := b.escalationRole(disastersv1.DisasterType_EARTHQUAKE); got != "quake-role" {
+		t.Errorf("escalationRole(EARTHQUAKE) = %q, want quake-role", got)
+	}
+	if got := b.escalationRole(disastersv1.DisasterType_FLOOD); got != "default-role" {
+		t.Errorf("escalationRole(FLOOD) = %q, want default-role", got)
+	}
+
+	b.cfg().EscalationRoles = nil
+	if got := b.escalationRole(disastersv1.DisasterType_FLOOD); got != "" {
+		t.Errorf("escalationRole(FLOOD) with no roles = %q, want empty", got)
+	}
+}
+
+func TestBot_Escalation(t *testing.T) {
+	b := newSubscriptionBot(t)
+	b.cfg().EscalationPopulationMultiple = 2
+
+	prev := &store.Record{
+		AlertLevel:        disastersv1.AlertLevel_ORANGE,
+		Population:        150000,
+		AlertedPopulation: 100000,
+	}
+
+	tests := []struct {
+		name     string
+		disaster *disastersv1.Disaster
+		want     string
+	}{
+		{"crosses into red", &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 150000}, "ORANGE → RED"},
+		{"population doubled since alert", &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 200000}, "affected population 100000 → 200000"},
+		{"population below multiple", &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 199999}, ""},
+		{"downgrade", &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 150000}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.escalation(prev, tt.disaster); got != tt.want {
+				t.Errorf("escalation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	alreadyRed := &store.Record{AlertLevel: disastersv1.AlertLevel_RED}
+	if got := b.escalation(alreadyRed, &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_RED}); got != "" {
+		t.Errorf("escalation() for already red = %q, want empty", got)
+	}
+
+	b.cfg().EscalationPopulationMultiple = 0
+	if got := b.escalation(prev, &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 1000000}); got != "" {
+		t.Errorf("escalation() with multiple disabled = %q, want empty", got)
+	}
+}
+
+func TestBot_Escalation_PerTypeMultiple(t *testing.T) {
+	b := newSubscriptionBot(t)
+	b.cfg().EscalationPopulationMultiple = 2
+	b.cfg().EscalationPopulationMultiples = map[disastersv1.DisasterType]float64{
+		disastersv1.DisasterType_EARTHQUAKE: 5,
+	}
+
+	prev := &store.Record{AlertLevel: disastersv1.AlertLevel_ORANGE, AlertedPopulation: 100000}
+	tripled := func(t disastersv1.DisasterType) *disastersv1.Disaster {
+		return &disastersv1.Disaster{Type: t, AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 300000}
+	}
+
+	if got := b.escalation(prev, tripled(disastersv1.DisasterType_FLOOD)); got == "" {
+		t.Error("escalation() for a flood at 3x = empty, want the global 2x to escalate it")
+	}
+	if got := b.escalation(prev, tripled(disastersv1.DisasterType_EARTHQUAKE)); got != "" {
+		t.Errorf("escalation() for an earthquake at 3x = %q, want empty below its 5x", got)
+	}
+
+	// "*" replaces the global multiple for types without their own
+	b.cfg().EscalationPopulationMultiples[disastersv1.DisasterType_UNSPECIFIED] = 4
+	if got := b.escalation(prev, tripled(disastersv1.DisasterType_FLOOD)); got != "" {
+		t.Errorf("escalation() for a flood at 3x = %q, want empty below the default 4x", got)
+	}
+}
+
+func TestBot_UpdateDisaster_Escalates(t *testing.T) {
+	channelID := "1234567890"
+	session := newMockSession(t, channelID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.cfg().EscalationRoles = map[disastersv1.DisasterType]string{disastersv1.DisasterType_FLOOD: "555"}
+	b.cfg().EscalationPopulationMultiple = 2
+
+	d := &disastersv1.Disaster{Id: "flood-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 100000}
+	messages, _ := b.deliver(context.Background(), d, []route{{channelID: channelID}})
+	b.markPosted(d, messages)
+
+	// Population grows but not past the multiple: edit only
+	b.updateDisaster(b.postedRecord(d.Id), &disastersv1.Disaster{Id: "flood-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 150000})
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 1 {
+		t.Fatalf("expected no escalation yet, got %d messages", len(channel.Messages))
+	}
+
+	// Crosses into RED: edit plus a fresh alert pinging the role
+	b.updateDisaster(b.postedRecord(d.Id), &disastersv1.Disaster{Id: "flood-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 150000})
+
+	if len(channel.Messages) != 2 {
+		t.Fatalf("expected an escalation message, got %d messages", len(channel.Messages))
+	}
+	escalation := channel.Messages[1]
+	if !strings.HasPrefix(escalation.Content, "<@&555> **ESCALATED:** ORANGE → RED") {
+		t.Errorf("escalation content = %q, want role ping and reason", escalation.Content)
+	}
+	if escalation.MessageReference == nil || escalation.MessageReference.MessageID != channel.Messages[0].ID {
+		t.Errorf("escalation should reply to the original message, got %+v", escalation.MessageReference)
+	}
+
+	// Already RED, population grows 1.5x from the last alert: no new escalation
+	b.updateDisaster(b.postedRecord(d.Id), &disastersv1.Disaster{Id: "flood-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 225000})
+	if len(channel.Messages) != 2 {
+		t.Errorf("expected no further escalation, got %d messages", len(channel.Messages))
+	}
+}
+
+func TestBot_UpdateDisaster_NoRoleNoEscalation(t *testing.T) {
+	channelID := "1234567890"
+	session := newMockSession(t, channelID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.cfg().EscalationRoles = map[disastersv1.DisasterType]string{disastersv1.DisasterType_EARTHQUAKE: "555"}
+
+	d := &disastersv1.Disaster{Id: "flood-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE}
+	messages, _ := b.deliver(context.Background(), d, []route{{channelID: channelID}})
+	b.markPosted(d, messages)
+	b.updateDisaster(b.postedRecord(d.Id), &disastersv1.Disaster{Id: "flood-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED})
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 1 {
+		t.Errorf("expected floods not to escalate, got %d messages", len(channel.Messages))
+	}
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Health is a snapshot of what the bot depends on, served by /healthz and
+// /readyz.
+type Health struct {
+	Status           string     `json:"status"` // "ok" or "unavailable"
+	Problems         []string   `json:"problems,omitempty"`
+	DiscordConnected bool       `json:"discord_connected"`
+	StreamConnected  bool       `json:"stream_connected"`
+	StreamUpstream   string     `json:"stream_upstream,omitempty"`
+	StreamSince      time.Time  `json:"stream_since"` // When the stream last connected or disconnected
+	LastMessage      *time.Time `json:"last_message,omitempty"`
+	LastMessageAge   float64    `json:"last_message_age_seconds,omitempty"`
+	IdleTimeouts     int        `json:"idle_timeouts"`
+	OutboxBacklog    int        `json:"outbox_backlog"`
+}
+
+// health reports the bot's state at now. With ready set, any disconnect is a
+// problem; otherwise the stream may be down for up to HealthStreamTimeout
+// while it reconnects.
+func (b *Bot) health(now time.Time, ready bool) Health {
+	st := b.StreamStatus()
+	h := Health{
+		DiscordConnected: b.discordConnected(),
+		StreamConnected:  st.Connected,
+		StreamUpstream:   st.Upstream,
+		StreamSince:      st.DisconnectedAt,
+		IdleTimeouts:     st.IdleTimeouts,
+	}
+	if st.Connected {
+		h.StreamSince = st.ConnectedAt
+	}
+	if !st.LastMessage.IsZero() {
+		h.LastMessage = &st.LastMessage
+		h.LastMessageAge = now.Sub(st.LastMessage).Seconds()
+	}
+
+	if entries, err := b.store.Outbox(); err != nil {
+		h.Problems = append(h.Problems, "outbox unreadable: "+err.Error())
+	} else {
+		h.OutboxBacklog = len(entries)
+	}
+
+	if !h.DiscordConnected {
+		h.Problems = append(h.Problems, "discord gateway not connected")
+	}
+	if !st.Connected {
+		down := now.Sub(st.DisconnectedAt)
+		if ready || down > b.cfg().HealthStreamTimeout {
+			h.Problems = append(h.Problems, "disaster stream down for "+down.Round(time.Second).String())
+		}
+	}
+
+	h.Status = "ok"
+	if len(h.Problems) > 0 {
+		h.Status = "unavailable"
+	}
+	return h
+}
+
+func (b *Bot) discordConnected() bool {
+	if b.session == nil {
+		return false
+	}
+	b.session.RLock()
+	defer b.session.RUnlock()
+	return b.session.DataReady
+}
+
+// ServeHealth reports whether the bot is alive: connected to Discord, with
+// the disaster stream up or only briefly down.
+func (b *Bot) ServeHealth(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, b.health(time.Now(), false))
+}
+
+// ServeReady reports whether the bot can deliver alerts right now.
+func (b *Bot) ServeReady(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, b.health(time.Now(), true))
+}
+
+func writeHealth(w http.ResponseWriter, h Health) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(h); err != nil {
+		slog.Error("Failed to write health response", "error", err)
+	}
+}
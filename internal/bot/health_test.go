@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+)
+
+func TestBot_Health(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		discord     bool
+		connected   bool
+		changedAgo  time.Duration // Since the stream last connected or disconnected
+		wantHealthy bool
+		wantReady   bool
+	}{
+		{"all up", true, true, time.Hour, true, true},
+		{"stream reconnecting", true, false, time.Minute, true, false},
+		{"stream down too long", true, false, 10 * time.Minute, false, false},
+		{"discord down", false, true, time.Hour, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newSubscriptionBot(t)
+			b.cfg().HealthStreamTimeout = 5 * time.Minute
+			b.session = &discordgo.Session{DataReady: tt.discord}
+			if tt.connected {
+				b.stream.connected("upstream:50051", now.Add(-tt.changedAgo))
+			} else {
+				b.stream.disconnected(false, now.Add(-tt.changedAgo))
+			}
+
+			if got := b.health(now, false); (got.Status == "ok") != tt.wantHealthy {
+				t.Errorf("health() = %+v, want healthy %v", got, tt.wantHealthy)
+			}
+			if got := b.health(now, true); (got.Status == "ok") != tt.wantReady {
+				t.Errorf("health(ready) = %+v, want ready %v", got, tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestBot_ServeHealth(t *testing.T) {
+	b := newSubscriptionBot(t)
+	b.cfg().HealthStreamTimeout = 5 * time.Minute
+	b.session = &discordgo.Session{DataReady: true}
+	b.stream.connected("upstream:50051", time.Now().Add(-time.Hour))
+	b.stream.received(time.Now().Add(-30 * time.Second))
+
+	e, _ := store.NewOutboxEntry(&disastersv1.Disaster{Id: "fl-1"})
+	if err := b.store.PutOutbox(e); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	b.ServeHealth(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", rec.Code, rec.Body)
+	}
+	var h Health
+	if err := json.Unmarshal(rec.Body.Bytes(), &h); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if !h.StreamConnected || h.StreamUpstream != "upstream:50051" || h.OutboxBacklog != 1 {
+		t.Errorf("health = %+v, want connected to upstream:50051 with 1 queued", h)
+	}
+	if h.LastMessageAge < 30 || h.LastMessageAge > 60 {
+		t.Errorf("last_message_age_seconds = %v, want about 30", h.LastMessageAge)
+	}
+
+	// Not ready until the stream is back
+	b.stream.disconnected(false, time.Now())
+	rec = httptest.NewRecorder()
+	b.ServeReady(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz status = %d, want 503", rec.Code)
+	}
+}
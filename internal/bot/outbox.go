@@ -0,0 +1,248 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/backoff"
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+const (
+	outboxMinBackoff = 5 * time.Second
+	outboxMaxBackoff = 10 * time.Minute
+)
+
+var outboxPolicy = backoff.Policy{Initial: outboxMinBackoff, Max: outboxMaxBackoff, Multiplier: 2}
+
+// enqueue queues a disaster in the outbox and makes the first delivery
+// attempt right away. Channels in posted already have a message and are
+// skipped. Routes that fail are retried by runOutbox. It returns how many
+// messages were posted.
+func (b *Bot) enqueue(ctx context.Context, d *disastersv1.Disaster, posted []store.Message) int {
+	e, err := store.NewOutboxEntry(d)
+	if err != nil {
+		slog.Error("Failed to queue disaster", "id", d.Id, "error", err)
+		return 0
+	}
+	e.Messages = slices.Clone(posted)
+	if err := b.store.PutOutbox(e); err != nil {
+		slog.Error("Failed to queue disaster", "id", d.Id, "error", err)
+		return 0
+	}
+
+	sent, _ := b.deliverEntry(ctx, e, d, time.Now())
+	return sent
+}
+
+// deliverEntry posts d to the routes of e that haven't been posted to yet.
+// Once every route is done the disaster is recorded as posted and queued for
+// acknowledgement; otherwise the entry is rescheduled with backoff. It returns
+// how many messages were posted and whether the entry left the outbox.
+func (b *Bot) deliverEntry(ctx context.Context, e *store.OutboxEntry, d *disastersv1.Disaster, now time.Time) (int, bool) {
+	delivered := make(map[string]bool, len(e.Messages))
+	for _, m := range e.Messages {
+		delivered[m.ChannelID] = true
+	}
+
+	var pending []route
+	for _, r := range b.routes(d) {
+		if !delivered[r.channelID] {
+			pending = append(pending, r)
+		}
+	}
+
+	messages, failed := b.deliver(ctx, d, pending)
+	e.Messages = append(e.Messages, messages...)
+
+	if len(failed) == 0 {
+		if len(e.Messages) == 0 {
+			// Nothing left to post to, e.g. every channel rejected the message
+			if err := b.store.DeleteOutbox(e.DisasterID); err != nil {
+				slog.Error("Failed to remove disaster from outbox", "id", e.DisasterID, "error", err)
+			}
+			return 0, true
+		}
+		b.markPosted(d, e.Messages)
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			b.ackLinks.Store(d.Id, sc)
+		}
+		b.queueAck()
+		return len(messages), true
+	}
+
+	e.Attempts++
+	e.NextAttempt = now.Add(outboxBackoff(e.Attempts))
+	if err := b.store.PutOutbox(e); err != nil {
+		slog.Error("Failed to reschedule disaster", "id", e.DisasterID, "error", err)
+	}
+	slog.Warn("Delivery incomplete, will retry", "id", e.DisasterID, "failed_channels", len(failed), "attempt", e.Attempts, "next_attempt", e.NextAttempt)
+	wake(b.outboxWake)
+	return len(messages), false
+}
+
+// runOutbox retries queued deliveries as they come due until ctx is done.
+func (b *Bot) runOutbox(ctx context.Context) {
+	for {
+		wait := outboxMaxBackoff
+		if next := b.flushOutbox(ctx, time.Now()); !next.IsZero() {
+			wait = max(time.Until(next), 0)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-b.outboxWake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// flushOutbox delivers every entry due at now and returns when the next one
+// is due, or the zero time if the outbox is empty.
+func (b *Bot) flushOutbox(ctx context.Context, now time.Time) time.Time {
+	entries, err := b.store.Outbox()
+	if err != nil {
+		slog.Error("Failed to read outbox", "error", err)
+		return now.Add(outboxMinBackoff)
+	}
+
+	var next time.Time
+	for _, e := range entries {
+		if e.NextAttempt.After(now) {
+			next = earliest(next, e.NextAttempt)
+			continue
+		}
+		if done := b.retryEntry(ctx, e.DisasterID, now); !done {
+			if retry, err := b.store.GetOutbox(e.DisasterID); err == nil {
+				next = earliest(next, retry.NextAttempt)
+			}
+		}
+	}
+	return next
+}
+
+// retryEntry redelivers a queued disaster, re-reading the entry under its
+// lock in case the stream updated or delivered it meanwhile.
+func (b *Bot) retryEntry(ctx context.Context, id string, now time.Time) bool {
+	unlock := b.lockDisaster(id)
+	defer unlock()
+
+	e, err := b.store.GetOutbox(id)
+	if err != nil {
+		return errors.Is(err, store.ErrNotFound)
+	}
+
+	ctx, span := tracer.Start(ctx, "disaster.retry", trace.WithAttributes(
+		attribute.String("disaster.id", id),
+		attribute.Int("outbox.attempt", e.Attempts+1),
+	))
+	defer span.End()
+
+	d, err := e.Disaster()
+	if err != nil {
+		slog.Error("Dropping undecodable outbox entry", "id", id, "error", err)
+		if err := b.store.DeleteOutbox(id); err != nil {
+			slog.Error("Failed to remove disaster from outbox", "id", id, "error", err)
+		}
+		return true
+	}
+
+	sent, done := b.deliverEntry(ctx, e, d, now)
+	if done && sent > 0 {
+		slog.Info("Posted queued disaster", "id", id, "attempts", e.Attempts+1)
+	}
+	return done
+}
+
+// disasterLock serializes the deliveries of one disaster. refs counts the
+// holders and waiters, so it can be dropped once nobody needs it.
+type disasterLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockDisaster serializes handling a disaster between the stream and the
+// outbox worker so it is never posted twice, and returns the unlock func.
+// Other disasters aren't held up, so a slow or rate-limited channel only
+// delays the disaster being posted to it.
+func (b *Bot) lockDisaster(id string) func() {
+	b.outboxMu.Lock()
+	l := b.inflight[id]
+	if l == nil {
+		if b.inflight == nil {
+			b.inflight = make(map[string]*disasterLock)
+		}
+		l = &disasterLock{}
+		b.inflight[id] = l
+	}
+	l.refs++
+	b.outboxMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		b.outboxMu.Lock()
+		defer b.outboxMu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(b.inflight, id)
+		}
+	}
+}
+
+// outboxBackoff returns the delay before the given retry attempt, doubling from
+// outboxMinBackoff up to outboxMaxBackoff.
+func outboxBackoff(attempt int) time.Duration {
+	return outboxPolicy.Delay(attempt)
+}
+
+// permanentDeliveryError reports whether posting failed because the channel
+// is gone or out of reach, so the channel should be dropped. Other client
+// errors, such as a 400 for a payload Discord rejects, say nothing about the
+// channel and are retried.
+func permanentDeliveryError(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil {
+		return false
+	}
+	if restErr.Message != nil {
+		switch restErr.Message.Code {
+		case discordgo.ErrCodeUnknownChannel, discordgo.ErrCodeMissingAccess, discordgo.ErrCodeMissingPermissions:
+			return true
+		}
+	}
+	switch restErr.Response.StatusCode {
+	case http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// wake nudges a worker loop without blocking if it is already awake.
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
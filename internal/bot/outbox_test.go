@@ -0,0 +1,227 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ewohltman/discordgo-mock/mockconstants"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	"github.com/mr1hm/disaster-alerts-bot/internal/subscriptions"
+)
+
+// failingTransport fails every request as if Discord were unreachable.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("discord unreachable")
+}
+
+// blockingTransport holds posts to one channel until release is closed,
+// like a rate-limited channel, and passes everything else to next.
+type blockingTransport struct {
+	channelID string
+	started   chan struct{}
+	release   chan struct{}
+	next      http.RoundTripper
+}
+
+func (t *blockingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.Contains(req.URL.Path, "/channels/"+t.channelID+"/") {
+		t.started <- struct{}{}
+		<-t.release
+	}
+	return t.next.RoundTrip(req)
+}
+
+func TestBot_RetryEntry_DoesNotBlockOtherDisasters(t *testing.T) {
+	slow, fast := "111111", "222222"
+	session := newMockSession(t, slow, fast)
+	transport := &blockingTransport{channelID: slow, started: make(chan struct{}), release: make(chan struct{}), next: session.Client.Transport}
+	session.Client = &http.Client{Transport: transport}
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.cfg().ChannelID = fast
+	if err := b.subscriptions.Add(subscriptions.Subscription{ChannelID: slow, Type: disastersv1.DisasterType_EARTHQUAKE}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A queued earthquake below the default threshold, retried into the slow channel
+	queued := &disastersv1.Disaster{Id: "eq-1", Type: disastersv1.DisasterType_EARTHQUAKE, Magnitude: 4}
+	e, err := store.NewOutboxEntry(queued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.store.PutOutbox(e); err != nil {
+		t.Fatal(err)
+	}
+	retried := make(chan bool)
+	go func() { retried <- b.retryEntry(context.Background(), queued.Id, time.Now()) }()
+	<-transport.started
+
+	handled := make(chan int)
+	go func() {
+		handled <- b.handleDisaster(context.Background(), &disastersv1.Disaster{Id: "fl-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED})
+	}()
+	select {
+	case sent := <-handled:
+		if sent != 1 {
+			t.Errorf("handleDisaster() = %d, want 1", sent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handleDisaster blocked behind a retry to another channel")
+	}
+
+	close(transport.release)
+	if done := <-retried; !done {
+		t.Error("retryEntry() = false, want the queued disaster delivered")
+	}
+	if !b.isPosted(queued.Id) {
+		t.Error("queued disaster not recorded as posted")
+	}
+}
+
+func TestBot_HandleDisaster_RetriesFailedDelivery(t *testing.T) {
+	channelID := mockconstants.TestChannel
+	session := newMockSession(t, channelID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.cfg().ChannelID = channelID
+
+	d := &disastersv1.Disaster{Id: "fl-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED}
+
+	// Discord is down: the disaster stays queued instead of being dropped
+	client := session.Client
+	session.Client = &http.Client{Transport: failingTransport{}}
+	if sent := b.handleDisaster(context.Background(), d); sent != 0 {
+		t.Fatalf("handleDisaster() = %d, want 0 while Discord is down", sent)
+	}
+	e, err := b.store.GetOutbox(d.Id)
+	if err != nil {
+		t.Fatalf("GetOutbox() error = %v, want queued entry", err)
+	}
+	if e.Attempts != 1 || e.NextAttempt.IsZero() {
+		t.Errorf("entry = %+v, want one attempt and a retry scheduled", e)
+	}
+	if b.isPosted(d.Id) {
+		t.Error("disaster marked posted before delivery succeeded")
+	}
+
+	// A re-delivery while queued updates the payload without posting
+	updated := &disastersv1.Disaster{Id: "fl-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 900000}
+	if sent := b.handleDisaster(context.Background(), updated); sent != 0 {
+		t.Errorf("handleDisaster() while queued = %d, want 0", sent)
+	}
+
+	// Nothing is due yet
+	session.Client = client
+	if next := b.flushOutbox(context.Background(), time.Now()); next.IsZero() {
+		t.Error("flushOutbox() next = zero, want the scheduled retry")
+	}
+
+	if next := b.flushOutbox(context.Background(), e.NextAttempt); !next.IsZero() {
+		t.Errorf("flushOutbox() next = %v, want empty outbox", next)
+	}
+
+	rec := b.postedRecord(d.Id)
+	if rec == nil || len(rec.Messages) != 1 || rec.Acked {
+		t.Fatalf("record = %+v, want one unacked message", rec)
+	}
+	if rec.Population != 900000 {
+		t.Errorf("recorded population = %d, want the updated payload", rec.Population)
+	}
+	if _, err := b.store.GetOutbox(d.Id); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetOutbox() error = %v, want entry removed", err)
+	}
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(channel.Messages))
+	}
+}
+
+func TestBot_DeliverEntry_RetriesOnlyFailedRoutes(t *testing.T) {
+	channelID := mockconstants.TestChannel
+	session := newMockSession(t, channelID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.cfg().ChannelID = channelID
+
+	d := &disastersv1.Disaster{Id: "fl-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED}
+	e, _ := store.NewOutboxEntry(d)
+	e.Messages = []store.Message{{ChannelID: channelID, MessageID: "already-posted"}}
+
+	sent, done := b.deliverEntry(context.Background(), e, d, time.Now())
+	if sent != 0 || !done {
+		t.Errorf("deliverEntry() = %d, %v, want 0, true", sent, done)
+	}
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 0 {
+		t.Errorf("expected no repost to a delivered channel, got %d messages", len(channel.Messages))
+	}
+	if rec := b.postedRecord(d.Id); rec == nil || rec.Messages[0].MessageID != "already-posted" {
+		t.Errorf("record = %+v, want the earlier message", rec)
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{4, 40 * time.Second},
+		{20, outboxMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempt); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPermanentDeliveryError(t *testing.T) {
+	restErr := func(code int) error {
+		return fmt.Errorf("posting: %w", &discordgo.RESTError{Response: &http.Response{StatusCode: code}})
+	}
+	apiErr := func(status, code int) error {
+		return &discordgo.RESTError{Response: &http.Response{StatusCode: status}, Message: &discordgo.APIErrorMessage{Code: code}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"missing channel", restErr(http.StatusNotFound), true},
+		{"missing permissions", restErr(http.StatusForbidden), true},
+		{"invalid payload", restErr(http.StatusBadRequest), false},
+		{"empty embed field", apiErr(http.StatusBadRequest, discordgo.ErrCodeInvalidFormBody), false},
+		{"unknown channel", apiErr(http.StatusBadRequest, discordgo.ErrCodeUnknownChannel), true},
+		{"missing access", apiErr(http.StatusBadRequest, discordgo.ErrCodeMissingAccess), true},
+		{"server error", restErr(http.StatusInternalServerError), false},
+		{"rate limited", restErr(http.StatusTooManyRequests), false},
+		{"network", errors.New("connection reset"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permanentDeliveryError(tt.err); got != tt.want {
+				t.Errorf("permanentDeliveryError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
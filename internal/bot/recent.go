@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+const (
+	recentPageSize     = 10
+	recentDefaultLimit = 25
+	recentMaxLimit     = 50
+	recentCustomID     = "recent"
+)
+
+var recentMinLimit = 1.0
+
+var recentCommand = &discordgo.ApplicationCommand{
+	Name:        "recent",
+	Description: "List the latest disasters",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "window",
+			Description: "How far back to look (default 24h)",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "1 hour", Value: "1h"},
+				{Name: "6 hours", Value: "6h"},
+				{Name: "12 hours", Value: "12h"},
+				{Name: "24 hours", Value: "24h"},
+				{Name: "3 days", Value: "72h"},
+				{Name: "7 days", Value: "168h"},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "type",
+			Description: "Only show this disaster type",
+			Choices:     disasterTypeChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "min_level",
+			Description: "Minimum alert level",
+			Choices:     alertLevelChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "limit",
+			Description: fmt.Sprintf("Maximum number of disasters (default %d)", recentDefaultLimit),
+			MinValue:    &recentMinLimit,
+			MaxValue:    recentMaxLimit,
+		},
+	},
+}
+
+// recentQuery holds the /recent filters. It is encoded into the pagination
+// button custom IDs so that page changes re-run the same query statelessly.
+type recentQuery struct {
+	Since    int64
+	Type     disastersv1.DisasterType
+	MinLevel disastersv1.AlertLevel
+	Limit    int32
+}
+
+func (q recentQuery) customID(page int) string {
+	return fmt.Sprintf("%s:%d:%d:%d:%d:%d", recentCustomID, page, q.Since, q.Type, q.MinLevel, q.Limit)
+}
+
+func parseRecentCustomID(id string) (recentQuery, int, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 6 || parts[0] != recentCustomID {
+		return recentQuery{}, 0, fmt.Errorf("invalid custom id %q", id)
+	}
+
+	nums := make([]int64, 5)
+	for i, p := range parts[1:] {
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return recentQuery{}, 0, fmt.Errorf("invalid custom id %q: %w", id, err)
+		}
+		nums[i] = n
+	}
+
+	q := recentQuery{
+		Since:    nums[1],
+		Type:     disastersv1.DisasterType(nums[2]),
+		MinLevel: disastersv1.AlertLevel(nums[3]),
+		Limit:    int32(nums[4]),
+	}
+	return q, int(nums[0]), nil
+}
+
+func recentQueryFromOptions(opts []*discordgo.ApplicationCommandInteractionDataOption, now time.Time) recentQuery {
+	window := 24 * time.Hour
+	if w := optionString(opts, "window"); w != "" {
+		if d, err := time.ParseDuration(w); err == nil {
+			window = d
+		}
+	}
+
+	q := recentQuery{
+		Since: now.Add(-window).Unix(),
+		Limit: recentDefaultLimit,
+	}
+	if t := optionString(opts, "type"); t != "" {
+		q.Type = disastersv1.DisasterType(disastersv1.DisasterType_value[t])
+	}
+	if l := optionString(opts, "min_level"); l != "" {
+		q.MinLevel = disastersv1.AlertLevel(disastersv1.AlertLevel_value[l])
+	}
+	if n := optionInt(opts, "limit"); n > 0 {
+		q.Limit = int32(min(n, recentMaxLimit))
+	}
+	return q
+}
+
+func (b *Bot) recentResponse(ctx context.Context, q recentQuery, page int) *discordgo.InteractionResponseData {
+	req := &disastersv1.ListDisastersRequest{
+		Limit: q.Limit,
+		Since: &q.Since,
+	}
+	if q.Type != disastersv1.DisasterType_UNSPECIFIED {
+		req.Type = &q.Type
+	}
+	if q.MinLevel != disastersv1.AlertLevel_UNKNOWN {
+		req.MinAlertLevel = &q.MinLevel
+	}
+
+	resp, err := b.client.ListDisasters(ctx, req)
+	if err != nil {
+		slog.Error("Failed to list recent disasters", "error", err)
+		return &discordgo.InteractionResponseData{Content: "Failed to fetch recent disasters, please try again later."}
+	}
+
+	disasters := resp.Disasters
+	if len(disasters) == 0 {
+		return &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("No disasters since <t:%d:R>.", q.Since),
+			Components: []discordgo.MessageComponent{},
+		}
+	}
+
+	pages := (len(disasters) + recentPageSize - 1) / recentPageSize
+	page = max(0, min(page, pages-1))
+	start := page * recentPageSize
+	end := min(start+recentPageSize, len(disasters))
+
+	lines := []string{
+		fmt.Sprintf("**%d disasters since <t:%d:R>** (page %d/%d)", len(disasters), q.Since, page+1, pages),
+	}
+	for _, d := range disasters[start:end] {
+		lines = append(lines, formatDisasterSummary(d))
+	}
+
+	return &discordgo.InteractionResponseData{
+		Content: strings.Join(lines, "\n"),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Previous",
+						Style:    discordgo.SecondaryButton,
+						CustomID: q.customID(page - 1),
+						Disabled: page == 0,
+					},
+					discordgo.Button{
+						Label:    "Next",
+						Style:    discordgo.SecondaryButton,
+						CustomID: q.customID(page + 1),
+						Disabled: page >= pages-1,
+					},
+				},
+			},
+		},
+	}
+}
+
+// formatDisasterSummary renders a disaster as a single line for lists:
+// 🔴 **EARTHQUAKE** Title · <t:...:R> · `id`
+func formatDisasterSummary(d *disastersv1.Disaster) string {
+	return fmt.Sprintf("%s **%s** %s · <t:%d:R> · `%s`",
+		getAlertEmoji(d.AlertLevel), d.Type.String(), d.Title, d.Timestamp, d.Id)
+}
+
+func disasterTypeChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for i := int32(1); i < int32(len(disastersv1.DisasterType_name)); i++ {
+		name := disastersv1.DisasterType(i).String()
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+	}
+	return choices
+}
+
+func alertLevelChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for i := int32(1); i < int32(len(disastersv1.AlertLevel_name)); i++ {
+		name := disastersv1.AlertLevel(i).String()
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+	}
+	return choices
+}
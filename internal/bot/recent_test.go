@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+)
+
+func TestRecentQuery_CustomIDRoundTrip(t *testing.T) {
+	q := recentQuery{
+		Since:    1767225600,
+		Type:     disastersv1.DisasterType_FLOOD,
+		MinLevel: disastersv1.AlertLevel_ORANGE,
+		Limit:    30,
+	}
+
+	got, page, err := parseRecentCustomID(q.customID(2))
+	if err != nil {
+		t.Fatalf("parseRecentCustomID() error = %v", err)
+	}
+	if got != q {
+		t.Errorf("parseRecentCustomID() query = %+v, want %+v", got, q)
+	}
+	if page != 2 {
+		t.Errorf("parseRecentCustomID() page = %d, want 2", page)
+	}
+
+	for _, bad := range []string{"", "recent:1:2", "other:0:0:0:0:0", "recent:a:0:0:0:0"} {
+		if _, _, err := parseRecentCustomID(bad); err == nil {
+			t.Errorf("parseRecentCustomID(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestRecentQueryFromOptions(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	opts := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "window", Type: discordgo.ApplicationCommandOptionString, Value: "6h"},
+		{Name: "type", Type: discordgo.ApplicationCommandOptionString, Value: "FLOOD"},
+		{Name: "min_level", Type: discordgo.ApplicationCommandOptionString, Value: "RED"},
+		{Name: "limit", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(10)},
+	}
+
+	q := recentQueryFromOptions(opts, now)
+	want := recentQuery{
+		Since:    now.Add(-6 * time.Hour).Unix(),
+		Type:     disastersv1.DisasterType_FLOOD,
+		MinLevel: disastersv1.AlertLevel_RED,
+		Limit:    10,
+	}
+	if q != want {
+		t.Errorf("recentQueryFromOptions() = %+v, want %+v", q, want)
+	}
+
+	q = recentQueryFromOptions(nil, now)
+	if q.Since != now.Add(-24*time.Hour).Unix() || q.Limit != recentDefaultLimit {
+		t.Errorf("recentQueryFromOptions(nil) = %+v, want 24h window and default limit", q)
+	}
+}
+
+func TestBot_RecentResponse_Pagination(t *testing.T) {
+	now := time.Now()
+
+	var disasters []*disastersv1.Disaster
+	for i := range 25 {
+		disasters = append(disasters, &disastersv1.Disaster{
+			Id:         fmt.Sprintf("d-%02d", i),
+			Title:      fmt.Sprintf("Disaster %d", i),
+			Type:       disastersv1.DisasterType_FLOOD,
+			AlertLevel: disastersv1.AlertLevel_ORANGE,
+			Timestamp:  now.Add(-time.Duration(i) * time.Minute).Unix(),
+		})
+	}
+
+	b := &Bot{
+		client: &fakeClient{disasters: disasters},
+	}
+	b.config.Store(&config.Config{})
+
+	q := recentQuery{Since: now.Add(-24 * time.Hour).Unix(), Limit: 25}
+
+	tests := []struct {
+		page         int
+		wantHeader   string
+		wantFirst    string
+		prevDisabled bool
+		nextDisabled bool
+	}{
+		{0, "(page 1/3)", "`d-00`", true, false},
+		{1, "(page 2/3)", "`d-10`", false, false},
+		{2, "(page 3/3)", "`d-20`", false, true},
+		{9, "(page 3/3)", "`d-20`", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantHeader, func(t *testing.T) {
+			resp := b.recentResponse(context.Background(), q, tt.page)
+
+			lines := strings.Split(resp.Content, "\n")
+			if !strings.Contains(lines[0], tt.wantHeader) {
+				t.Errorf("header = %q, want to contain %q", lines[0], tt.wantHeader)
+			}
+			if !strings.Contains(lines[1], tt.wantFirst) {
+				t.Errorf("first entry = %q, want to contain %q", lines[1], tt.wantFirst)
+			}
+
+			row := resp.Components[0].(discordgo.ActionsRow)
+			prev := row.Components[0].(discordgo.Button)
+			next := row.Components[1].(discordgo.Button)
+			if prev.Disabled != tt.prevDisabled {
+				t.Errorf("previous disabled = %v, want %v", prev.Disabled, tt.prevDisabled)
+			}
+			if next.Disabled != tt.nextDisabled {
+				t.Errorf("next disabled = %v, want %v", next.Disabled, tt.nextDisabled)
+			}
+		})
+	}
+}
+
+func TestBot_RecentResponse_Filters(t *testing.T) {
+	now := time.Now()
+
+	b := &Bot{
+		client: &fakeClient{disasters: []*disastersv1.Disaster{
+			{Id: "flood-red", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, Timestamp: now.Unix()},
+			{Id: "flood-green", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: now.Unix()},
+			{Id: "quake-red", Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_RED, Timestamp: now.Unix()},
+			{Id: "flood-old", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED, Timestamp: now.Add(-48 * time.Hour).Unix()},
+		}},
+	}
+	b.config.Store(&config.Config{})
+
+	resp := b.recentResponse(context.Background(), recentQuery{
+		Since:    now.Add(-24 * time.Hour).Unix(),
+		Type:     disastersv1.DisasterType_FLOOD,
+		MinLevel: disastersv1.AlertLevel_ORANGE,
+		Limit:    recentDefaultLimit,
+	}, 0)
+
+	if !strings.Contains(resp.Content, "flood-red") {
+		t.Errorf("response missing flood-red: %q", resp.Content)
+	}
+	for _, id := range []string{"flood-green", "quake-red", "flood-old"} {
+		if strings.Contains(resp.Content, id) {
+			t.Errorf("response should not contain %s: %q", id, resp.Content)
+		}
+	}
+
+	empty := b.recentResponse(context.Background(), recentQuery{
+		Since: now.Add(time.Hour).Unix(),
+		Limit: recentDefaultLimit,
+	}, 0)
+	if !strings.HasPrefix(empty.Content, "No disasters since") {
+		t.Errorf("empty response = %q, want no disasters message", empty.Content)
+	}
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"log/slog"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+)
+
+// Reload swaps in the filtering and routing settings from cfg, which must
+// already be validated, leaving the stream and Discord session alone. Changes
+// to other settings are ignored until the next restart, and each is logged
+// once rather than on every reload. Reload must not be called concurrently
+// with itself.
+func (b *Bot) Reload(cfg *config.Config) {
+	reloaded, applied, ignored := b.cfg().Reload(cfg)
+
+	// The running config keeps the old values, so pending changes come back
+	// on every reload until the restart
+	pending := make(map[string]config.Change, len(ignored))
+	var fresh []config.Change
+	for _, c := range ignored {
+		pending[c.Key] = c
+		if prev, ok := b.restartPending[c.Key]; !ok || prev != c {
+			fresh = append(fresh, c)
+		}
+	}
+	b.restartPending = pending
+
+	if len(applied) == 0 && len(fresh) == 0 {
+		slog.Info("Config reloaded, nothing changed", "pending_restart", len(pending))
+		return
+	}
+
+	if len(applied) > 0 {
+		b.config.Store(reloaded)
+	}
+	for _, c := range applied {
+		slog.Info("Config changed", "key", c.Key, "old", c.Old, "new", c.New)
+	}
+	for _, c := range fresh {
+		slog.Warn("Config change needs a restart, ignoring", "key", c.Key, "old", c.Old, "new", c.New)
+	}
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+func TestBot_Reload(t *testing.T) {
+	b := newSubscriptionBot(t)
+
+	orange := &disastersv1.Disaster{Id: "fl-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE}
+	if !b.shouldPost(orange) {
+		t.Fatal("shouldPost(ORANGE) = false before reload, want true")
+	}
+
+	next := *b.cfg()
+	next.AlertLevel = disastersv1.AlertLevel_RED
+	next.ChannelID = "other"
+	next.StorePath = "other.db"
+	before := b.cfg()
+
+	b.Reload(&next)
+
+	if b.shouldPost(orange) {
+		t.Error("shouldPost(ORANGE) = true after raising ALERT_LEVEL to RED, want false")
+	}
+	red := &disastersv1.Disaster{Id: "fl-2", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED}
+	if routes := b.routes(red); len(routes) != 1 || routes[0].channelID != "other" {
+		t.Errorf("routes() = %+v, want the reloaded default channel", routes)
+	}
+	if b.cfg().StorePath != "" {
+		t.Errorf("StorePath = %q, want the setting that needs a restart left alone", b.cfg().StorePath)
+	}
+	if before.AlertLevel != disastersv1.AlertLevel_ORANGE {
+		t.Error("Reload() modified the config in use instead of swapping it")
+	}
+}
+
+func TestBot_Reload_Unchanged(t *testing.T) {
+	b := newSubscriptionBot(t)
+	before := b.cfg()
+
+	same := *before
+	b.Reload(&same)
+
+	if b.cfg() != before {
+		t.Error("Reload() of an unchanged config swapped it")
+	}
+}
+
+func TestBot_Reload_LogsRestartChangeOnce(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	b := newSubscriptionBot(t)
+	warnings := func() int {
+		return strings.Count(logs.String(), "needs a restart")
+	}
+
+	next := *b.cfg()
+	next.StorePath = "other.db"
+	for range 3 {
+		again := next
+		b.Reload(&again)
+	}
+	if n := warnings(); n != 1 {
+		t.Errorf("logged the pending store_path change %d times over three reloads, want once", n)
+	}
+
+	next.StorePath = "third.db"
+	b.Reload(&next)
+	if n := warnings(); n != 2 {
+		t.Errorf("logged %d restart warnings after editing the pending change again, want 2", n)
+	}
+
+	reverted := *b.cfg()
+	b.Reload(&reverted)
+	next.StorePath = "third.db"
+	b.Reload(&next)
+	if n := warnings(); n != 3 {
+		t.Errorf("logged %d restart warnings after reverting and repeating a change, want 3", n)
+	}
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/geo"
+	"github.com/mr1hm/disaster-alerts-bot/internal/metrics"
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// route is a channel a disaster will be posted to. area is the closest
+// geofence among the channel's matching subscriptions. If none of them are
+// geofenced it is the nearest area the channel is subscribed to, with outside
+// set, or nil if the channel has no areas.
+type route struct {
+	channelID  string
+	area       geo.Area
+	distanceKm float64
+	outside    bool
+}
+
+// routes returns where a disaster should be posted: the default channel if it
+// passes the global filter, plus every subscribed channel whose filter it
+// matches and every configured route whose rule it matches. Each channel
+// appears once.
+func (b *Bot) routes(d *disastersv1.Disaster) []route {
+	cfg := b.cfg()
+
+	var routes []route
+	if channelID := cfg.ChannelID; channelID != "" && b.shouldPost(d) {
+		routes = append(routes, route{channelID: channelID})
+	}
+
+	p := geo.Point{Lat: d.Latitude, Lon: d.Longitude}
+	index := make(map[string]int, len(routes))
+	for i, r := range routes {
+		index[r.channelID] = i
+	}
+
+	for _, sub := range b.subscriptions.Match(d) {
+		i, ok := index[sub.ChannelID]
+		if !ok {
+			i = len(routes)
+			index[sub.ChannelID] = i
+			routes = append(routes, route{channelID: sub.ChannelID})
+		}
+
+		if sub.Area == nil {
+			continue
+		}
+		dist := areaDistance(sub.Area, p)
+		if routes[i].area == nil || dist < routes[i].distanceKm {
+			routes[i].area = sub.Area
+			routes[i].distanceKm = dist
+		}
+	}
+
+	for _, r := range cfg.Routes {
+		if _, ok := index[r.ChannelID]; ok {
+			continue
+		}
+		match, err := r.Rule.Match(d)
+		if err != nil {
+			slog.Warn("Failed to evaluate route rule", "id", d.Id, "channel_id", r.ChannelID, "rule", r.Rule.String(), "error", err)
+			continue
+		}
+		if match {
+			index[r.ChannelID] = len(routes)
+			routes = append(routes, route{channelID: r.ChannelID})
+		}
+	}
+
+	for i := range routes {
+		if routes[i].area != nil {
+			continue
+		}
+		if area, dist := geo.Nearest(b.subscribedAreas(routes[i].channelID), p); area != nil {
+			routes[i].area, routes[i].distanceKm, routes[i].outside = area, dist, true
+		}
+	}
+	return routes
+}
+
+// subscribedAreas returns the geofences of a channel's subscriptions.
+func (b *Bot) subscribedAreas(channelID string) []geo.Area {
+	if b.subscriptions == nil {
+		return nil
+	}
+	var areas []geo.Area
+	for _, sub := range b.subscriptions.List(channelID) {
+		if sub.Area != nil {
+			areas = append(areas, sub.Area)
+		}
+	}
+	return areas
+}
+
+// deliver posts d to each route and returns the messages that were sent and
+// the routes worth retrying. Routes that fail permanently are logged and
+// dropped.
+func (b *Bot) deliver(ctx context.Context, d *disastersv1.Disaster, routes []route) (messages []store.Message, failed []route) {
+	for _, r := range routes {
+		start := time.Now()
+		msg, err := b.postDisaster(ctx, r, d)
+		metrics.PostLatency.Observe(time.Since(start).Seconds())
+		if err != nil {
+			if permanentDeliveryError(err) {
+				metrics.PostFailures.WithLabelValues("permanent").Inc()
+				slog.Error("Failed to post disaster, dropping channel", "id", d.Id, "channel_id", r.channelID, "error", err)
+				continue
+			}
+			metrics.PostFailures.WithLabelValues("retryable").Inc()
+			slog.Error("Failed to post disaster", "id", d.Id, "channel_id", r.channelID, "error", err)
+			failed = append(failed, r)
+			continue
+		}
+
+		metrics.MessagesPosted.Inc()
+		if d.Timestamp > 0 {
+			metrics.DeliveryDelay.Observe(time.Since(time.Unix(d.Timestamp, 0)).Seconds())
+		}
+		messages = append(messages, store.Message{
+			ChannelID: r.channelID,
+			MessageID: msg.ID,
+			Distance:  r.distance(),
+		})
+	}
+	return messages, failed
+}
+
+// areaDistance measures how close p is to an area it falls within: the
+// distance from a circle's center, or zero for polygons.
+func areaDistance(a geo.Area, p geo.Point) float64 {
+	if c, ok := a.(geo.Circle); ok {
+		return geo.Distance(c.Center, p)
+	}
+	return a.DistanceKm(p)
+}
+
+// distance renders how far the disaster is from the route's area, or "" if
+// the route has no area.
+func (r route) distance() string {
+	if r.area == nil {
+		return ""
+	}
+	return formatAreaDistance(r)
+}
+
+func formatAreaDistance(r route) string {
+	if r.outside {
+		return fmt.Sprintf("%.0f km outside %s", r.distanceKm, r.area.Name())
+	}
+	if _, ok := r.area.(geo.Circle); ok {
+		return fmt.Sprintf("%.0f km from %s", r.distanceKm, r.area.Name())
+	}
+	return fmt.Sprintf("Inside %s", r.area.Name())
+}
@@ -0,0 +1,218 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+	"github.com/mr1hm/disaster-alerts-bot/internal/geo"
+	"github.com/mr1hm/disaster-alerts-bot/internal/rules"
+	"github.com/mr1hm/disaster-alerts-bot/internal/subscriptions"
+)
+
+func routeChannelIDs(routes []route) []string {
+	var ids []string
+	for _, r := range routes {
+		ids = append(ids, r.channelID)
+	}
+	return ids
+}
+
+func TestBot_Routes(t *testing.T) {
+	b := newSubscriptionBot(t)
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "floods", Type: disastersv1.DisasterType_FLOOD, MinAlertLevel: disastersv1.AlertLevel_RED})
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "everything"})
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "default", Type: disastersv1.DisasterType_FLOOD})
+
+	tests := []struct {
+		name     string
+		disaster *disastersv1.Disaster
+		want     []string
+	}{
+		{
+			name:     "red flood goes everywhere once",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED},
+			want:     []string{"default", "floods", "everything"},
+		},
+		{
+			name:     "green cyclone only to catch-all",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_GREEN},
+			want:     []string{"everything"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := routeChannelIDs(b.routes(tt.disaster))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("routes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBot_Routes_Rules(t *testing.T) {
+	b := newSubscriptionBot(t)
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "floods", Type: disastersv1.DisasterType_FLOOD})
+	b.cfg().Routes = []config.Route{
+		{ChannelID: "south-asia", Rule: mustCompile(t, "type == FLOOD && population > 1000000 && lat >= 5 && lat <= 38 && lon >= 60 && lon <= 98 || alert_level == RED")},
+		{ChannelID: "floods", Rule: mustCompile(t, "true")},
+		{ChannelID: "broken", Rule: mustCompile(t, "population / 0 > 1")},
+	}
+
+	tests := []struct {
+		name     string
+		disaster *disastersv1.Disaster
+		want     []string
+	}{
+		{
+			name:     "large flood in Nepal",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 2000000, Latitude: 27.7, Longitude: 85.3},
+			want:     []string{"default", "floods", "south-asia"},
+		},
+		{
+			name:     "small flood in Nepal",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 1000, Latitude: 27.7, Longitude: 85.3},
+			want:     []string{"floods"},
+		},
+		{
+			name:     "red anywhere",
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_RED, Latitude: -20, Longitude: -70},
+			want:     []string{"default", "south-asia", "floods"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := routeChannelIDs(b.routes(tt.disaster))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("routes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustCompile(t *testing.T, expr string) *rules.Rule {
+	t.Helper()
+	rule, err := rules.Compile(expr)
+	if err != nil {
+		t.Fatalf("Compile(%q) error = %v", expr, err)
+	}
+	return rule
+}
+
+func TestBot_Routes_NearestArea(t *testing.T) {
+	b := newSubscriptionBot(t)
+	b.cfg().ChannelID = ""
+
+	jakarta := geo.Circle{Label: "Jakarta office", Center: geo.Point{Lat: -6.2088, Lon: 106.8456}, RadiusKm: 500}
+	bandung := geo.Circle{Label: "Bandung office", Center: geo.Point{Lat: -6.9175, Lon: 107.6191}, RadiusKm: 500}
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "staff", Area: jakarta})
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "staff", Area: bandung})
+
+	// Closer to Bandung than Jakarta, inside both radii
+	routes := b.routes(&disastersv1.Disaster{Latitude: -7.0, Longitude: 107.5})
+	if len(routes) != 1 {
+		t.Fatalf("routes() len = %d, want 1", len(routes))
+	}
+	if routes[0].area.Name() != "Bandung office" {
+		t.Errorf("nearest area = %q, want Bandung office", routes[0].area.Name())
+	}
+	if got := formatAreaDistance(routes[0]); got != "16 km from Bandung office" {
+		t.Errorf("formatAreaDistance() = %q", got)
+	}
+
+	// Outside both areas
+	if routes := b.routes(&disastersv1.Disaster{Latitude: 35.6, Longitude: 139.6}); len(routes) != 0 {
+		t.Errorf("routes() = %v, want none outside areas", routeChannelIDs(routes))
+	}
+}
+
+func TestBot_Routes_DistanceOutsideAreas(t *testing.T) {
+	b := newSubscriptionBot(t)
+	b.cfg().ChannelID = ""
+
+	jakarta := geo.Circle{Label: "Jakarta office", Center: geo.Point{Lat: -6.2088, Lon: 106.8456}, RadiusKm: 50}
+	coast := &geo.Polygon{Label: "Coast", Rings: [][]geo.Point{{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}, {Lat: 1, Lon: 1}, {Lat: 1, Lon: 0}, {Lat: 0, Lon: 0}}}}
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "staff", Type: disastersv1.DisasterType_FLOOD, Area: jakarta})
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "staff", Type: disastersv1.DisasterType_FLOOD, Area: coast})
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: "staff", Type: disastersv1.DisasterType_EARTHQUAKE})
+
+	tests := []struct {
+		name     string
+		disaster *disastersv1.Disaster
+		want     string
+	}{
+		{"nearest circle", &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, Latitude: -6.2088, Longitude: 107.8456}, "61 km outside Jakarta office"},
+		{"nearest polygon", &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, Latitude: 0.5, Longitude: 2}, "111 km outside Coast"},
+		{"inside polygon", &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, Latitude: 0.5, Longitude: 0.5}, "Inside Coast"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := b.routes(tt.disaster)
+			if len(routes) != 1 {
+				t.Fatalf("routes() = %v, want staff", routeChannelIDs(routes))
+			}
+			if got := routes[0].distance(); got != tt.want {
+				t.Errorf("distance() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBot_PostDisaster_IncludesDistance(t *testing.T) {
+	channelID := "1234567890"
+	session := newMockSession(t, channelID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+
+	polygon := &geo.Polygon{Label: "Coast", Rings: [][]geo.Point{{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}, {Lat: 1, Lon: 1}, {Lat: 1, Lon: 0}, {Lat: 0, Lon: 0}}}}
+	r := route{channelID: channelID, area: polygon}
+
+	if _, err := b.postDisaster(context.Background(), r, &disastersv1.Disaster{Id: "d-1", Title: "Flood", Latitude: 0.5, Longitude: 0.5}); err != nil {
+		t.Fatalf("postDisaster() error = %v", err)
+	}
+
+	b.cfg().MessageFormat = config.MessageFormatText
+	if _, err := b.postDisaster(context.Background(), r, &disastersv1.Disaster{Id: "d-2", Title: "Flood", Latitude: 0.5, Longitude: 0.5}); err != nil {
+		t.Fatalf("postDisaster() error = %v", err)
+	}
+
+	channel, _ := session.State.Channel(channelID)
+	fields := channel.Messages[0].Embeds[0].Fields
+	if last := fields[len(fields)-1]; last.Name != "Distance" || last.Value != "Inside Coast" {
+		t.Errorf("embed distance field = %+v, want Inside Coast", last)
+	}
+	if !strings.Contains(channel.Messages[1].Content, "**DISTANCE:** Inside Coast") {
+		t.Errorf("message missing distance line: %q", channel.Messages[1].Content)
+	}
+}
+
+func TestBot_Deliver(t *testing.T) {
+	channelID := "1234567890"
+	session := newMockSession(t, channelID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+
+	d := &disastersv1.Disaster{Id: "d-1", Title: "Flood", Type: disastersv1.DisasterType_FLOOD}
+
+	// The unknown channel fails but the known one still gets the post
+	got, failed := b.deliver(context.Background(), d, []route{{channelID: "missing"}, {channelID: channelID}})
+	if len(got) != 1 {
+		t.Errorf("deliver() sent %d messages, want 1", len(got))
+	}
+	if len(failed) != 1 || failed[0].channelID != "missing" {
+		t.Errorf("deliver() failed = %v, want [missing]", routeChannelIDs(failed))
+	}
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 1 {
+		t.Errorf("expected 1 message, got %d", len(channel.Messages))
+	}
+}
@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/rules"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// RuleResult is how a rule evaluated for one disaster. Err is set if the
+// rule failed to evaluate, which a route treats as no match.
+type RuleResult struct {
+	Disaster *disastersv1.Disaster
+	Match    bool
+	Err      error
+}
+
+// TestRule evaluates rule against every disaster since since, oldest first,
+// without posting anything.
+func (b *Bot) TestRule(ctx context.Context, rule *rules.Rule, since time.Time) ([]RuleResult, error) {
+	disasters, err := b.listDisastersSince(ctx, since.Unix(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("listing disasters: %w", err)
+	}
+
+	results := make([]RuleResult, len(disasters))
+	for i, d := range disasters {
+		match, err := rule.Match(d)
+		results[i] = RuleResult{Disaster: d, Match: match, Err: err}
+	}
+	return results, nil
+}
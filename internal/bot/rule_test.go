@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+func TestBot_TestRule(t *testing.T) {
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	client := &fakeClient{disasters: []*disastersv1.Disaster{
+		{Id: "too-old", AlertLevel: disastersv1.AlertLevel_RED, Timestamp: base.Add(-time.Hour).Unix()},
+		{Id: "red", AlertLevel: disastersv1.AlertLevel_RED, Timestamp: base.Add(2 * time.Hour).Unix()},
+		{Id: "green", AlertLevel: disastersv1.AlertLevel_GREEN, Timestamp: base.Add(time.Hour).Unix()},
+	}}
+	b := &Bot{client: client}
+
+	results, err := b.TestRule(context.Background(), mustCompile(t, "alert_level == RED"), base)
+	if err != nil {
+		t.Fatalf("TestRule() error = %v", err)
+	}
+
+	want := []struct {
+		id    string
+		match bool
+	}{{"green", false}, {"red", true}}
+	if len(results) != len(want) {
+		t.Fatalf("TestRule() returned %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		if r := results[i]; r.Disaster.Id != w.id || r.Match != w.match || r.Err != nil {
+			t.Errorf("results[%d] = %s %v %v, want %s %v", i, r.Disaster.Id, r.Match, r.Err, w.id, w.match)
+		}
+	}
+	if client.listCalls == 0 {
+		t.Error("TestRule() didn't list disasters")
+	}
+}
+
+func TestBot_TestRule_EvalError(t *testing.T) {
+	client := &fakeClient{disasters: []*disastersv1.Disaster{{Id: "d-1", Timestamp: 2000}}}
+	b := &Bot{client: client}
+
+	results, err := b.TestRule(context.Background(), mustCompile(t, "population / 0 > 1"), time.Unix(1000, 0))
+	if err != nil {
+		t.Fatalf("TestRule() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Err == nil || results[0].Match {
+		t.Errorf("TestRule() = %+v, want one failed evaluation", results)
+	}
+}
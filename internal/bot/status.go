@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// StreamStatus describes the disaster stream for logs and health checks.
+type StreamStatus struct {
+	Connected      bool
+	Upstream       string
+	ConnectedAt    time.Time
+	DisconnectedAt time.Time // When the stream last went down, or the bot started
+	LastMessage    time.Time // Zero until the first disaster arrives
+	IdleTimeouts   int       // Streams torn down by the idle watchdog since start
+}
+
+// streamState tracks the current StreamStatus.
+type streamState struct {
+	mu     sync.Mutex
+	status StreamStatus
+}
+
+func (s *streamState) connected(upstream string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Connected = true
+	s.status.Upstream = upstream
+	s.status.ConnectedAt = now
+}
+
+func (s *streamState) received(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.LastMessage = now
+}
+
+func (s *streamState) disconnected(idle bool, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Connected = false
+	s.status.DisconnectedAt = now
+	if idle {
+		s.status.IdleTimeouts++
+	}
+}
+
+func (s *streamState) get() StreamStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// StreamStatus returns the current state of the disaster stream.
+func (b *Bot) StreamStatus() StreamStatus {
+	return b.stream.get()
+}
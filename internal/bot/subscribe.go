@@ -0,0 +1,202 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/geo"
+	"github.com/mr1hm/disaster-alerts-bot/internal/subscriptions"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// manageChannels restricts subscription commands to channel admins by default.
+// Server owners can still override this per command in the integration settings.
+var manageChannels int64 = discordgo.PermissionManageChannels
+
+// dmPermission hides subscription commands in DMs, where there are no
+// permissions to check and no channel admins.
+var dmPermission = false
+
+var (
+	minLatitude  = -90.0
+	minLongitude = -180.0
+	minRadiusKm  = 1.0
+)
+
+var subscribeCommand = &discordgo.ApplicationCommand{
+	Name:                     "subscribe",
+	Description:              "Route matching disasters into this channel",
+	DefaultMemberPermissions: &manageChannels,
+	DMPermission:             &dmPermission,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "type",
+			Description: "Disaster type (default: all types)",
+			Choices:     disasterTypeChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "min_level",
+			Description: "Minimum alert level (default: all levels)",
+			Choices:     alertLevelChoices(),
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "area",
+			Description: "Geofence name from the geofence file, or a label for lat/lon/radius_km",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionNumber,
+			Name:        "lat",
+			Description: "Latitude of the area center",
+			MinValue:    &minLatitude,
+			MaxValue:    90,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionNumber,
+			Name:        "lon",
+			Description: "Longitude of the area center",
+			MinValue:    &minLongitude,
+			MaxValue:    180,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionNumber,
+			Name:        "radius_km",
+			Description: "Radius around lat/lon in kilometers",
+			MinValue:    &minRadiusKm,
+		},
+	},
+}
+
+var unsubscribeCommand = &discordgo.ApplicationCommand{
+	Name:                     "unsubscribe",
+	Description:              "Stop routing disasters into this channel",
+	DefaultMemberPermissions: &manageChannels,
+	DMPermission:             &dmPermission,
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "type",
+			Description: "Disaster type to remove (default: all subscriptions)",
+			Choices:     disasterTypeChoices(),
+		},
+	},
+}
+
+func (b *Bot) subscribeResponse(channelID, guildID string, opts []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionResponseData {
+	sub := subscriptions.Subscription{
+		ChannelID: channelID,
+		GuildID:   guildID,
+	}
+	if t := optionString(opts, "type"); t != "" {
+		sub.Type = disastersv1.DisasterType(disastersv1.DisasterType_value[t])
+	}
+	if l := optionString(opts, "min_level"); l != "" {
+		sub.MinAlertLevel = disastersv1.AlertLevel(disastersv1.AlertLevel_value[l])
+	}
+
+	area, err := b.subscriptionArea(opts)
+	if err != nil {
+		return &discordgo.InteractionResponseData{Content: fmt.Sprintf("Invalid area: %v.", err)}
+	}
+	sub.Area = area
+
+	if err := b.subscriptions.Add(sub); err != nil {
+		slog.Error("Failed to save subscription", "channel_id", channelID, "error", err)
+		return &discordgo.InteractionResponseData{Content: "Failed to save subscription, please try again later."}
+	}
+
+	slog.Info("Channel subscribed", "channel_id", channelID, "type", sub.Type, "min_level", sub.MinAlertLevel)
+	return &discordgo.InteractionResponseData{
+		Content: "Subscribed.\n" + formatSubscriptions(b.subscriptions.List(channelID)),
+	}
+}
+
+func (b *Bot) unsubscribeResponse(channelID string, opts []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionResponseData {
+	typ := disastersv1.DisasterType_UNSPECIFIED
+	if t := optionString(opts, "type"); t != "" {
+		typ = disastersv1.DisasterType(disastersv1.DisasterType_value[t])
+	}
+
+	removed, err := b.subscriptions.Remove(channelID, typ)
+	if err != nil {
+		slog.Error("Failed to remove subscription", "channel_id", channelID, "error", err)
+		return &discordgo.InteractionResponseData{Content: "Failed to remove subscription, please try again later."}
+	}
+	if removed == 0 {
+		return &discordgo.InteractionResponseData{Content: "No matching subscription in this channel."}
+	}
+
+	slog.Info("Channel unsubscribed", "channel_id", channelID, "type", typ, "removed", removed)
+	return &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("Removed %d subscription(s).\n%s", removed, formatSubscriptions(b.subscriptions.List(channelID))),
+	}
+}
+
+// subscriptionArea builds the geofence for /subscribe: a circle when
+// lat/lon/radius_km are given, otherwise the named polygon if area is set.
+// Errors are shown to the user.
+func (b *Bot) subscriptionArea(opts []*discordgo.ApplicationCommandInteractionDataOption) (geo.Area, error) {
+	name := optionString(opts, "area")
+	lat, hasLat := optionFloat(opts, "lat")
+	lon, hasLon := optionFloat(opts, "lon")
+	radius, hasRadius := optionFloat(opts, "radius_km")
+
+	if hasLat || hasLon || hasRadius {
+		if !hasLat || !hasLon || !hasRadius {
+			return nil, errors.New("a radius area needs all of `lat`, `lon` and `radius_km`")
+		}
+		if name == "" {
+			name = fmt.Sprintf("%.4f, %.4f", lat, lon)
+		}
+		return geo.Circle{Label: name, Center: geo.Point{Lat: lat, Lon: lon}, RadiusKm: radius}, nil
+	}
+
+	if name == "" {
+		return nil, nil
+	}
+
+	polygon, ok := b.areas[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(b.areas))
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown area `%s`, no geofences are configured", name)
+		}
+		return nil, fmt.Errorf("unknown area `%s`, available areas: %s", name, strings.Join(names, ", "))
+	}
+	return polygon, nil
+}
+
+func formatSubscriptions(subs []subscriptions.Subscription) string {
+	if len(subs) == 0 {
+		return "This channel has no subscriptions."
+	}
+
+	lines := []string{"**Subscriptions in this channel:**"}
+	for _, sub := range subs {
+		typ := "All types"
+		if sub.Type != disastersv1.DisasterType_UNSPECIFIED {
+			typ = sub.Type.String()
+		}
+		level := "any level"
+		if sub.MinAlertLevel != disastersv1.AlertLevel_UNKNOWN {
+			level = fmt.Sprintf("%s %s+", getAlertEmoji(sub.MinAlertLevel), sub.MinAlertLevel)
+		}
+		line := fmt.Sprintf("- %s, %s", typ, level)
+		switch area := sub.Area.(type) {
+		case geo.Circle:
+			line += fmt.Sprintf(", within %.0f km of %s", area.RadiusKm, area.Label)
+		case *geo.Polygon:
+			line += fmt.Sprintf(", inside %s", area.Label)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
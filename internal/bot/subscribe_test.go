@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+	"github.com/mr1hm/disaster-alerts-bot/internal/geo"
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	"github.com/mr1hm/disaster-alerts-bot/internal/subscriptions"
+)
+
+func newSubscriptionBot(t *testing.T) *Bot {
+	t.Helper()
+
+	subs, err := subscriptions.Load("", nil)
+	if err != nil {
+		t.Fatalf("subscriptions.Load() error = %v", err)
+	}
+
+	b := &Bot{
+		subscriptions: subs,
+		store:         store.NewMemory(),
+	}
+	b.config.Store(&config.Config{
+		ChannelID:     "default",
+		MinMagnitude:  5.0,
+		AlertLevel:    disastersv1.AlertLevel_ORANGE,
+		MinPopulation: 500000,
+	})
+	return b
+}
+
+func TestBot_SubscribeAndUnsubscribe(t *testing.T) {
+	b := newSubscriptionBot(t)
+
+	resp := b.subscribeResponse("111", "guild", []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "type", Type: discordgo.ApplicationCommandOptionString, Value: "FLOOD"},
+		{Name: "min_level", Type: discordgo.ApplicationCommandOptionString, Value: "RED"},
+	})
+	if !strings.Contains(resp.Content, "FLOOD, 🔴 RED+") {
+		t.Errorf("subscribe response = %q, want FLOOD RED+ listed", resp.Content)
+	}
+
+	subs := b.subscriptions.List("111")
+	if len(subs) != 1 || subs[0].Type != disastersv1.DisasterType_FLOOD || subs[0].MinAlertLevel != disastersv1.AlertLevel_RED {
+		t.Fatalf("subscriptions = %+v, want one FLOOD/RED", subs)
+	}
+
+	resp = b.unsubscribeResponse("111", nil)
+	if !strings.Contains(resp.Content, "Removed 1 subscription") {
+		t.Errorf("unsubscribe response = %q, want removal", resp.Content)
+	}
+
+	resp = b.unsubscribeResponse("111", nil)
+	if !strings.Contains(resp.Content, "No matching subscription") {
+		t.Errorf("second unsubscribe response = %q, want no match", resp.Content)
+	}
+}
+
+func TestBot_SubscribeWithArea(t *testing.T) {
+	b := newSubscriptionBot(t)
+	b.areas = map[string]*geo.Polygon{
+		"coast": {Label: "coast", Rings: [][]geo.Point{{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}, {Lat: 1, Lon: 1}, {Lat: 0, Lon: 0}}}},
+	}
+
+	str := func(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+	}
+	num := func(name string, value float64) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionNumber, Value: value}
+	}
+
+	tests := []struct {
+		name string
+		opts []*discordgo.ApplicationCommandInteractionDataOption
+		want string
+	}{
+		{"radius", []*discordgo.ApplicationCommandInteractionDataOption{str("area", "Tokyo"), num("lat", 35.6), num("lon", 139.6), num("radius_km", 200)}, "within 200 km of Tokyo"},
+		{"radius without label", []*discordgo.ApplicationCommandInteractionDataOption{num("lat", 35.6), num("lon", 139.6), num("radius_km", 50)}, "within 50 km of 35.6000, 139.6000"},
+		{"polygon", []*discordgo.ApplicationCommandInteractionDataOption{str("area", "coast")}, "inside coast"},
+		{"incomplete radius", []*discordgo.ApplicationCommandInteractionDataOption{num("lat", 35.6)}, "needs all of `lat`, `lon` and `radius_km`"},
+		{"unknown polygon", []*discordgo.ApplicationCommandInteractionDataOption{str("area", "moon")}, "available areas: coast"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := b.subscribeResponse("111", "guild", tt.opts)
+			if !strings.Contains(resp.Content, tt.want) {
+				t.Errorf("subscribeResponse() = %q, want to contain %q", resp.Content, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+var tracer = otel.Tracer("github.com/mr1hm/disaster-alerts-bot/internal/bot")
+
+// disasterAttributes describes d on a span. The age is how long after its
+// timestamp the disaster reached the bot, i.e. the upstream delay.
+func disasterAttributes(d *disastersv1.Disaster) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("disaster.id", d.Id),
+		attribute.String("disaster.type", d.Type.String()),
+		attribute.String("disaster.alert_level", d.AlertLevel.String()),
+	}
+	if d.Timestamp > 0 {
+		attrs = append(attrs, attribute.Float64("disaster.age_seconds", time.Since(time.Unix(d.Timestamp, 0)).Seconds()))
+	}
+	return attrs
+}
+
+// spanError marks span as failed with err.
+func spanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
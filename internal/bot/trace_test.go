@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ewohltman/discordgo-mock/mockconstants"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+func TestBot_HandleDisaster_Traces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	channelID := mockconstants.TestChannel
+	session := newMockSession(t, channelID)
+
+	client := &fakeClient{}
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.client = client
+	b.cfg().ChannelID = channelID
+
+	d := &disastersv1.Disaster{Id: "fl-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED}
+	if sent := b.handleDisaster(context.Background(), d); sent != 1 {
+		t.Fatalf("handleDisaster() = %d, want 1", sent)
+	}
+	if err := b.flushAcks(context.Background()); err != nil {
+		t.Fatalf("flushAcks() error = %v", err)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	for _, name := range []string{"disaster.handle", "disaster.filter", "disaster.post", "disasters.acknowledge"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("missing span %q, got %v", name, recorder.Ended())
+		}
+	}
+
+	handle := spans["disaster.handle"].SpanContext()
+	for _, name := range []string{"disaster.filter", "disaster.post"} {
+		if parent := spans[name].Parent(); parent.SpanID() != handle.SpanID() {
+			t.Errorf("%s parent = %v, want disaster.handle", name, parent.SpanID())
+		}
+	}
+
+	links := spans["disasters.acknowledge"].Links()
+	if len(links) != 1 || links[0].SpanContext.TraceID() != handle.TraceID() {
+		t.Errorf("acknowledge links = %v, want a link to the disaster's trace", links)
+	}
+}
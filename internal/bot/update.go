@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// recordChanges describes how d differs from what was posted for rec, e.g.
+// "ORANGE → RED". It returns nil if nothing relevant changed.
+func recordChanges(rec *store.Record, d *disastersv1.Disaster) []string {
+	var changes []string
+	if d.AlertLevel != rec.AlertLevel {
+		changes = append(changes, fmt.Sprintf("%s → %s", rec.AlertLevel, d.AlertLevel))
+	}
+	if d.AffectedPopulationCount != rec.Population {
+		changes = append(changes, fmt.Sprintf("population %d → %d", rec.Population, d.AffectedPopulationCount))
+	}
+	if d.Magnitude != rec.Magnitude {
+		changes = append(changes, fmt.Sprintf("magnitude %.1f → %.1f", rec.Magnitude, d.Magnitude))
+	}
+	return changes
+}
+
+// updateDisaster edits the messages previously posted for d if its alert
+// level, population or magnitude changed since, and posts an escalation
+// alert if the change is severe enough. Re-deliveries with an unchanged
+// payload are ignored. It reports whether the payload changed. prev is
+// refreshed from the store before it is changed and saved.
+func (b *Bot) updateDisaster(prev *store.Record, d *disastersv1.Disaster) bool {
+	hash := store.PayloadHash(d)
+	if hash == prev.PayloadHash {
+		return false
+	}
+
+	var reason string
+	role := b.escalationRole(d.Type)
+
+	b.mu.Lock()
+	// prev was read without the lock; re-read it so fields changed since,
+	// such as Acked, aren't overwritten with stale values
+	if cur, err := b.store.Get(d.Id); err == nil {
+		*prev = *cur
+	}
+	if hash == prev.PayloadHash {
+		b.mu.Unlock()
+		return false
+	}
+	changes := recordChanges(prev, d)
+	if len(changes) > 0 {
+		if role != "" {
+			reason = b.escalation(prev, d)
+		}
+		prev.AlertLevel = d.AlertLevel
+		prev.Population = d.AffectedPopulationCount
+		prev.Magnitude = d.Magnitude
+		if reason != "" {
+			prev.AlertedPopulation = d.AffectedPopulationCount
+		}
+	}
+	prev.PayloadHash = hash
+	prev.UpdatedAt = time.Now()
+	err := b.store.Put(prev)
+	b.mu.Unlock()
+
+	if err != nil {
+		slog.Error("Failed to save disaster", "id", d.Id, "error", err)
+	}
+
+	if len(changes) == 0 {
+		return true
+	}
+
+	for _, m := range prev.Messages {
+		if err := b.editDisaster(m, d, changes); err != nil {
+			slog.Error("Failed to edit disaster", "id", d.Id, "channel_id", m.ChannelID, "message_id", m.MessageID, "error", err)
+		}
+	}
+
+	slog.Info("Updated disaster", "id", d.Id, "changes", changes, "messages", len(prev.Messages))
+
+	if reason != "" {
+		sent := b.escalate(d, prev.Messages, role, reason)
+		slog.Info("Escalated disaster", "id", d.Id, "reason", reason, "role_id", role, "alerts", sent)
+	}
+	return true
+}
+
+// postNewRoutes posts an updated disaster to the channels it routes to now
+// but wasn't posted to before, e.g. a subscription for RED disasters after an
+// upgrade from ORANGE. They go through the outbox like a new disaster, and
+// their messages are added to prev's. It returns how many were posted.
+func (b *Bot) postNewRoutes(ctx context.Context, prev *store.Record, d *disastersv1.Disaster) int {
+	posted := make(map[string]bool, len(prev.Messages))
+	for _, m := range prev.Messages {
+		posted[m.ChannelID] = true
+	}
+	if !slices.ContainsFunc(b.routes(d), func(r route) bool { return !posted[r.channelID] }) {
+		return 0
+	}
+
+	if e, err := b.store.GetOutbox(d.Id); err == nil {
+		// Already retrying new channels: deliver the latest values when it runs
+		if err := e.SetDisaster(d); err == nil {
+			err = b.store.PutOutbox(e)
+		}
+		if err != nil {
+			slog.Error("Failed to update queued disaster", "id", d.Id, "error", err)
+		}
+		return 0
+	}
+
+	sent := b.enqueue(ctx, d, prev.Messages)
+	if sent > 0 {
+		slog.Info("Posted updated disaster to new channels", "id", d.Id, "messages", sent)
+	}
+	return sent
+}
+
+// editDisaster re-renders a posted message with d's current values and a line
+// noting what changed.
+func (b *Bot) editDisaster(m store.Message, d *disastersv1.Disaster, changes []string) error {
+	msg := b.disasterMessage(d, m.Distance)
+	edit := discordgo.NewMessageEdit(m.ChannelID, m.MessageID)
+	note := strings.Join(changes, ", ")
+
+	if len(msg.Embeds) > 0 {
+		msg.Embeds[0].Fields = append(msg.Embeds[0].Fields, &discordgo.MessageEmbedField{
+			Name:  "Updated",
+			Value: note,
+		})
+		edit.SetEmbeds(msg.Embeds)
+	} else {
+		edit.SetContent(fmt.Sprintf("%s\n**UPDATED:** %s", msg.Content, note))
+	}
+
+	_, err := b.session.ChannelMessageEditComplex(edit)
+	return err
+}
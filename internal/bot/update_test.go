@@ -0,0 +1,218 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/config"
+	"github.com/mr1hm/disaster-alerts-bot/internal/store"
+	"github.com/mr1hm/disaster-alerts-bot/internal/subscriptions"
+)
+
+func TestRecordChanges(t *testing.T) {
+	prev := &store.Record{
+		AlertLevel: disastersv1.AlertLevel_ORANGE,
+		Population: 100000,
+		Magnitude:  6.1,
+	}
+
+	tests := []struct {
+		name     string
+		disaster *disastersv1.Disaster
+		want     []string
+	}{
+		{
+			name:     "unchanged",
+			disaster: &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_ORANGE, AffectedPopulationCount: 100000, Magnitude: 6.1},
+			want:     nil,
+		},
+		{
+			name:     "alert upgraded",
+			disaster: &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_RED, AffectedPopulationCount: 100000, Magnitude: 6.1},
+			want:     []string{"ORANGE → RED"},
+		},
+		{
+			name:     "everything changed",
+			disaster: &disastersv1.Disaster{AlertLevel: disastersv1.AlertLevel_GREEN, AffectedPopulationCount: 250000, Magnitude: 6.4},
+			want:     []string{"ORANGE → GREEN", "population 100000 → 250000", "magnitude 6.1 → 6.4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := recordChanges(prev, tt.disaster)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("recordChanges() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBot_UpdateDisaster_EditsMessages(t *testing.T) {
+	for _, format := range []string{config.MessageFormatEmbed, config.MessageFormatText} {
+		t.Run(format, func(t *testing.T) {
+			channelID := "1234567890"
+			session := newMockSession(t, channelID)
+
+			b := newSubscriptionBot(t)
+			b.session = session
+			b.cfg().MessageFormat = format
+
+			d := &disastersv1.Disaster{
+				Id:         "flood-1",
+				Title:      "Flood in Bangladesh",
+				Type:       disastersv1.DisasterType_FLOOD,
+				AlertLevel: disastersv1.AlertLevel_ORANGE,
+			}
+			messages, _ := b.deliver(context.Background(), d, []route{{channelID: channelID}})
+			b.markPosted(d, messages)
+
+			// Re-delivery with no changes leaves the message alone
+			b.updateDisaster(b.postedRecord(d.Id), d)
+
+			channel, _ := session.State.Channel(channelID)
+			if text := messageText(channel.Messages[0]); strings.Contains(text, "RED") {
+				t.Fatalf("message edited without changes: %q", text)
+			}
+
+			upgraded := &disastersv1.Disaster{
+				Id:         "flood-1",
+				Title:      "Flood in Bangladesh",
+				Type:       disastersv1.DisasterType_FLOOD,
+				AlertLevel: disastersv1.AlertLevel_RED,
+			}
+			b.updateDisaster(b.postedRecord(d.Id), upgraded)
+
+			if len(channel.Messages) != 1 {
+				t.Fatalf("expected the original message to be edited, got %d messages", len(channel.Messages))
+			}
+			if text := messageText(channel.Messages[0]); !strings.Contains(text, "ORANGE → RED") {
+				t.Errorf("edited message missing update note: %q", text)
+			}
+			if got := b.postedRecord(d.Id).AlertLevel; got != disastersv1.AlertLevel_RED {
+				t.Errorf("recorded alert level = %v, want RED", got)
+			}
+		})
+	}
+}
+
+func TestBot_StreamDisasters_UpdatesReDelivered(t *testing.T) {
+	channelID := "1234567890"
+	session := newMockSession(t, channelID)
+
+	client := &fakeClient{stream: make(chan *disastersv1.Disaster)}
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.client = client
+	b.cfg().ChannelID = channelID
+
+	done := make(chan error)
+	go func() {
+		_, err := b.streamDisasters(context.Background())
+		done <- err
+	}()
+
+	client.stream <- &disastersv1.Disaster{Id: "cy-1", Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_ORANGE}
+	client.stream <- &disastersv1.Disaster{Id: "cy-1", Type: disastersv1.DisasterType_CYCLONE, AlertLevel: disastersv1.AlertLevel_RED}
+	close(client.stream)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("streamDisasters did not return after stream closed")
+	}
+
+	channel, _ := session.State.Channel(channelID)
+	if len(channel.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(channel.Messages))
+	}
+	if text := messageText(channel.Messages[0]); !strings.Contains(text, "ORANGE → RED") {
+		t.Errorf("message not updated: %q", text)
+	}
+	if err := b.flushAcks(context.Background()); err != nil {
+		t.Fatalf("flushAcks() error = %v", err)
+	}
+	if acked := client.ackedIDs(); len(acked) != 1 {
+		t.Errorf("acked = %v, want a single ack", acked)
+	}
+}
+
+func TestBot_UpdateDisaster_KeepsAck(t *testing.T) {
+	channelID := "1234567890"
+	b := newSubscriptionBot(t)
+	b.session = newMockSession(t, channelID)
+	b.client = &fakeClient{}
+
+	d := &disastersv1.Disaster{Id: "flood-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE}
+	messages, _ := b.deliver(context.Background(), d, []route{{channelID: channelID}})
+	b.markPosted(d, messages)
+
+	// The update read the record before the ack was recorded
+	prev := b.postedRecord(d.Id)
+	if err := b.acknowledgeDisasters(context.Background(), []string{d.Id}); err != nil {
+		t.Fatalf("acknowledgeDisasters() error = %v", err)
+	}
+	b.updateDisaster(prev, &disastersv1.Disaster{Id: "flood-1", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED})
+
+	rec := b.postedRecord(d.Id)
+	if !rec.Acked {
+		t.Error("update overwrote the ack")
+	}
+	if rec.AlertLevel != disastersv1.AlertLevel_RED {
+		t.Errorf("recorded alert level = %v, want RED", rec.AlertLevel)
+	}
+}
+
+func TestBot_HandleDisaster_UpgradePostsToNewChannels(t *testing.T) {
+	defaultID, redID := "1234567890", "2345678901"
+	session := newMockSession(t, defaultID, redID)
+
+	b := newSubscriptionBot(t)
+	b.session = session
+	b.cfg().ChannelID = defaultID
+	_ = b.subscriptions.Add(subscriptions.Subscription{ChannelID: redID, Type: disastersv1.DisasterType_FLOOD, MinAlertLevel: disastersv1.AlertLevel_RED})
+
+	ctx := context.Background()
+	orange := &disastersv1.Disaster{Id: "flood-1", Title: "Flood in Bangladesh", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE}
+	if sent := b.handleDisaster(ctx, orange); sent != 1 {
+		t.Fatalf("handleDisaster(ORANGE) posted %d messages, want 1", sent)
+	}
+
+	red := &disastersv1.Disaster{Id: "flood-1", Title: "Flood in Bangladesh", Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED}
+	if sent := b.handleDisaster(ctx, red); sent != 1 {
+		t.Fatalf("handleDisaster(RED) posted %d messages, want 1", sent)
+	}
+
+	defaultChannel, _ := session.State.Channel(defaultID)
+	if len(defaultChannel.Messages) != 1 || !strings.Contains(messageText(defaultChannel.Messages[0]), "ORANGE → RED") {
+		t.Errorf("default channel should have one edited message, got %d", len(defaultChannel.Messages))
+	}
+	redChannel, _ := session.State.Channel(redID)
+	if len(redChannel.Messages) != 1 {
+		t.Fatalf("RED subscription got %d messages after the upgrade, want 1", len(redChannel.Messages))
+	}
+
+	rec := b.postedRecord("flood-1")
+	var channels []string
+	for _, m := range rec.Messages {
+		channels = append(channels, m.ChannelID)
+	}
+	if strings.Join(channels, ",") != defaultID+","+redID {
+		t.Errorf("recorded channels = %v, want both", channels)
+	}
+	if rec.AlertLevel != disastersv1.AlertLevel_RED {
+		t.Errorf("recorded alert level = %v, want RED", rec.AlertLevel)
+	}
+	if _, err := b.store.GetOutbox("flood-1"); err == nil {
+		t.Error("disaster left in the outbox")
+	}
+
+	// A repeat of the update posts nothing more
+	if sent := b.handleDisaster(ctx, red); sent != 0 {
+		t.Errorf("re-delivered update posted %d messages, want 0", sent)
+	}
+}
@@ -1,47 +1,357 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
 )
 
+const (
+	MessageFormatEmbed = "embed"
+	MessageFormatText  = "text"
+)
+
+// DefaultHTTPAddress is where the metrics and health endpoints listen unless
+// HTTP_ADDRESS says otherwise.
+const DefaultHTTPAddress = ":9090"
+
 type Config struct {
-	Token        string
-	ChannelID    string
-	GRPCAddress  string
-	MinMagnitude float64
-	AlertLevel   disastersv1.AlertLevel
+	Token             string
+	ChannelID         string
+	GuildID           string
+	GRPCAddresses     []string
+	MinMagnitude      float64
+	AlertLevel        disastersv1.AlertLevel
+	MinPopulation     int64
+	EphemeralReplies  bool
+	SubscriptionsPath string
+	GeofencesPath     string
+	StorePath         string
+	MessageFormat     string
+	HTTPAddress       string // Serves /metrics, /healthz and /readyz; empty disables
+
+	// Thresholds overrides the default channel's filter per disaster type.
+	// UNSPECIFIED holds the filter for types without their own entry; without
+	// it, MinMagnitude, AlertLevel and MinPopulation apply. See Threshold.
+	Thresholds map[disastersv1.DisasterType]Threshold
+
+	// Routes are rule-based channels, set in the config file only.
+	Routes []Route
+
+	// EscalationRoles maps a disaster type to the role pinged when a posted
+	// disaster of that type escalates. UNSPECIFIED holds the default for types
+	// without their own entry. Types with no role don't escalate.
+	EscalationRoles map[disastersv1.DisasterType]string
+
+	// EscalationPopulationMultiples maps a disaster type to the multiple of
+	// the population at the last alert that escalates it, with UNSPECIFIED
+	// as the default. EscalationPopulationMultiple applies to types with
+	// neither. Zero disables population escalations.
+	EscalationPopulationMultiples map[disastersv1.DisasterType]float64
+	EscalationPopulationMultiple  float64
+
+	// Reconnect* control the backoff between stream reconnect attempts.
+	// ReconnectMaxRetries is how many consecutive failed attempts are allowed
+	// before giving up; 0 retries forever.
+	ReconnectInitialInterval time.Duration
+	ReconnectMaxInterval     time.Duration
+	ReconnectMultiplier      float64
+	ReconnectJitter          float64
+	ReconnectMaxRetries      int
+
+	// BackfillWindow is how far back unsent disasters are fetched on startup.
+	// BackfillPageSize is the first ListDisasters limit when paging through
+	// them; it doubles until every result fits.
+	BackfillWindow   time.Duration
+	BackfillPageSize int32
+
+	// GRPCTLS enables TLS to the upstream server. It is implied by setting a
+	// CA or client certificate. Without GRPCCAFile the system roots are used;
+	// GRPCCertFile and GRPCKeyFile enable mutual TLS. GRPCServerName overrides
+	// the name checked against the server's certificate.
+	GRPCTLS        bool
+	GRPCCAFile     string
+	GRPCCertFile   string
+	GRPCKeyFile    string
+	GRPCServerName string
+
+	// GRPCAuthToken is sent as a bearer token with every upstream call.
+	// GRPCAuthTokenFile takes precedence and is re-read when it changes.
+	// Tokens are only sent over TLS unless GRPCAuthAllowInsecure is set.
+	GRPCAuthToken         string
+	GRPCAuthTokenFile     string
+	GRPCAuthAllowInsecure bool
+
+	// GRPCHealthCheckInterval is how often each upstream is probed when more
+	// than one is configured.
+	GRPCHealthCheckInterval time.Duration
+
+	// GRPCKeepalive* set how often the connection is pinged while idle and
+	// how long to wait for the reply before treating it as dead; a zero time
+	// disables pings. StreamIdleTimeout reconnects the stream when no
+	// disaster arrives for that long; zero, the default, disables it.
+	// Disasters only arrive as upstream ingests them, so long silences are
+	// normal and keepalive is what detects a dead connection.
+	GRPCKeepaliveTime    time.Duration
+	GRPCKeepaliveTimeout time.Duration
+	StreamIdleTimeout    time.Duration
+
+	// HealthStreamTimeout is how long the stream may be down before /healthz
+	// reports the bot unhealthy. /readyz fails as soon as it drops.
+	HealthStreamTimeout time.Duration
+
+	// TracingExporter is where OpenTelemetry spans are sent: "otlp", "stdout",
+	// or "" for no tracing.
+	TracingExporter string
+}
+
+// Load builds the configuration from the defaults, then the YAML file at path
+// if one is given, then environment variables. Each layer overrides the one
+// before it. If any setting is missing or invalid, Load returns a
+// *ValidationError listing all of them.
+func Load(path string) (*Config, error) {
+	return load(path, (*Config).validate)
+}
+
+// LoadUpstream loads the configuration like Load, but only checks the
+// settings for reaching the upstream server, so tools that just list
+// disasters run without the Discord settings.
+func LoadUpstream(path string) (*Config, error) {
+	return load(path, (*Config).validateUpstream)
+}
+
+func load(path string, validate func(*Config, *ValidationError)) (*Config, error) {
+	cfg := defaults()
+	errs := &ValidationError{}
+	if path != "" {
+		if err := cfg.loadFile(path, errs); err != nil {
+			return nil, fmt.Errorf("loading config file: %w", err)
+		}
+	}
+	cfg.loadEnv(errs)
+
+	if cfg.GRPCCAFile != "" || cfg.GRPCCertFile != "" {
+		cfg.GRPCTLS = true
+	}
+
+	validate(cfg, errs)
+	if len(errs.Errors) > 0 {
+		return nil, errs
+	}
+	return cfg, nil
 }
 
-func Load() (*Config, error) {
-	cfg := &Config{
-		Token:        os.Getenv("DISCORD_TOKEN"),
-		ChannelID:    os.Getenv("DISCORD_CHANNEL_ID"),
-		GRPCAddress:  getEnvOrDefault("GRPC_ADDRESS", "localhost:50051"),
-		MinMagnitude: 5.0,
-		AlertLevel:   disastersv1.AlertLevel_ORANGE,
+func defaults() *Config {
+	return &Config{
+		GRPCAddresses:     []string{"localhost:50051"},
+		MinMagnitude:      5.0,
+		AlertLevel:        disastersv1.AlertLevel_ORANGE,
+		MinPopulation:     500000,
+		EphemeralReplies:  true,
+		SubscriptionsPath: "subscriptions.json",
+		StorePath:         "state.db",
+		MessageFormat:     MessageFormatEmbed,
+		HTTPAddress:       DefaultHTTPAddress,
+
+		Thresholds: make(map[disastersv1.DisasterType]Threshold),
+
+		EscalationRoles:               make(map[disastersv1.DisasterType]string),
+		EscalationPopulationMultiples: make(map[disastersv1.DisasterType]float64),
+		EscalationPopulationMultiple:  2.0,
+
+		ReconnectInitialInterval: time.Second,
+		ReconnectMaxInterval:     time.Minute,
+		ReconnectMultiplier:      2.0,
+		ReconnectJitter:          0.2,
+		ReconnectMaxRetries:      5,
+
+		BackfillWindow:   24 * time.Hour,
+		BackfillPageSize: 50,
+
+		GRPCHealthCheckInterval: 10 * time.Second,
+
+		GRPCKeepaliveTime:    5 * time.Minute,
+		GRPCKeepaliveTimeout: 20 * time.Second,
+
+		HealthStreamTimeout: 5 * time.Minute,
 	}
+}
 
-	if minMag := os.Getenv("MIN_MAGNITUDE"); minMag != "" {
-		if mag, err := strconv.ParseFloat(minMag, 64); err == nil {
-			cfg.MinMagnitude = mag
+// loadEnv overrides cfg with the environment variables that are set. Values
+// that don't parse are added to errs; ranges are checked by validate.
+func (cfg *Config) loadEnv(errs *ValidationError) {
+	vars := map[string]*string{
+		"DISCORD_TOKEN":        &cfg.Token,
+		"DISCORD_CHANNEL_ID":   &cfg.ChannelID,
+		"DISCORD_GUILD_ID":     &cfg.GuildID,
+		"MESSAGE_FORMAT":       &cfg.MessageFormat,
+		"SUBSCRIPTIONS_PATH":   &cfg.SubscriptionsPath,
+		"GEOFENCES_PATH":       &cfg.GeofencesPath,
+		"STORE_PATH":           &cfg.StorePath,
+		"GRPC_TLS_CA_FILE":     &cfg.GRPCCAFile,
+		"GRPC_TLS_CERT_FILE":   &cfg.GRPCCertFile,
+		"GRPC_TLS_KEY_FILE":    &cfg.GRPCKeyFile,
+		"GRPC_TLS_SERVER_NAME": &cfg.GRPCServerName,
+		"GRPC_AUTH_TOKEN":      &cfg.GRPCAuthToken,
+		"GRPC_AUTH_TOKEN_FILE": &cfg.GRPCAuthTokenFile,
+		"TRACING_EXPORTER":     &cfg.TracingExporter,
+	}
+	for key, field := range vars {
+		if val := os.Getenv(key); val != "" {
+			*field = val
 		}
 	}
 
+	if addr, ok := os.LookupEnv("HTTP_ADDRESS"); ok {
+		cfg.HTTPAddress = addr
+	}
+
+	if addrs := os.Getenv("GRPC_ADDRESS"); addrs != "" {
+		cfg.GRPCAddresses = parseList(addrs)
+	}
+
 	if al := os.Getenv("ALERT_LEVEL"); al != "" {
 		if val, ok := disastersv1.AlertLevel_value[al]; ok {
 			cfg.AlertLevel = disastersv1.AlertLevel(val)
+		} else {
+			errs.add("ALERT_LEVEL", "unknown alert level %q", al)
 		}
 	}
 
-	return cfg, nil
+	if roles := os.Getenv("ESCALATION_ROLES"); roles != "" {
+		cfg.EscalationRoles = parseEscalationRoles(roles, errs)
+	}
+	if mults := os.Getenv("ESCALATION_POPULATION_MULTIPLES"); mults != "" {
+		cfg.EscalationPopulationMultiples = parsePopulationMultiples(mults, errs)
+	}
+
+	envFloat(errs, "MIN_MAGNITUDE", &cfg.MinMagnitude)
+	envInt(errs, "MIN_POPULATION", &cfg.MinPopulation)
+	envFloat(errs, "ESCALATION_POPULATION_MULTIPLE", &cfg.EscalationPopulationMultiple)
+	envFloat(errs, "RECONNECT_MULTIPLIER", &cfg.ReconnectMultiplier)
+	envFloat(errs, "RECONNECT_JITTER", &cfg.ReconnectJitter)
+	envInt(errs, "RECONNECT_MAX_RETRIES", &cfg.ReconnectMaxRetries)
+	envInt(errs, "BACKFILL_PAGE_SIZE", &cfg.BackfillPageSize)
+
+	envBool(errs, "EPHEMERAL_REPLIES", &cfg.EphemeralReplies)
+	envBool(errs, "GRPC_TLS", &cfg.GRPCTLS)
+	envBool(errs, "GRPC_AUTH_ALLOW_INSECURE", &cfg.GRPCAuthAllowInsecure)
+
+	envDuration(errs, "RECONNECT_INITIAL_INTERVAL", &cfg.ReconnectInitialInterval)
+	envDuration(errs, "RECONNECT_MAX_INTERVAL", &cfg.ReconnectMaxInterval)
+	envDuration(errs, "BACKFILL_WINDOW", &cfg.BackfillWindow)
+	envDuration(errs, "GRPC_HEALTH_CHECK_INTERVAL", &cfg.GRPCHealthCheckInterval)
+	envDuration(errs, "GRPC_KEEPALIVE_TIME", &cfg.GRPCKeepaliveTime)
+	envDuration(errs, "GRPC_KEEPALIVE_TIMEOUT", &cfg.GRPCKeepaliveTimeout)
+	envDuration(errs, "STREAM_IDLE_TIMEOUT", &cfg.StreamIdleTimeout)
+	envDuration(errs, "HEALTH_STREAM_TIMEOUT", &cfg.HealthStreamTimeout)
+}
+
+func envFloat(errs *ValidationError, key string, dst *float64) {
+	if s := os.Getenv(key); s != "" {
+		if val, err := strconv.ParseFloat(s, 64); err == nil {
+			*dst = val
+		} else {
+			errs.add(key, "%q is not a number", s)
+		}
+	}
+}
+
+func envInt[T int | int32 | int64](errs *ValidationError, key string, dst *T) {
+	if s := os.Getenv(key); s != "" {
+		if val, err := strconv.ParseInt(s, 10, 64); err == nil && int64(T(val)) == val {
+			*dst = T(val)
+		} else {
+			errs.add(key, "%q is not an integer", s)
+		}
+	}
+}
+
+func envBool(errs *ValidationError, key string, dst *bool) {
+	if s := os.Getenv(key); s != "" {
+		if val, err := strconv.ParseBool(s); err == nil {
+			*dst = val
+		} else {
+			errs.add(key, "%q is not true or false", s)
+		}
+	}
+}
+
+func envDuration(errs *ValidationError, key string, dst *time.Duration) {
+	if s := os.Getenv(key); s != "" {
+		if val, err := time.ParseDuration(s); err == nil {
+			*dst = val
+		} else {
+			errs.add(key, "%q is not a duration like 30s or 5m", s)
+		}
+	}
+}
+
+// parseList splits a comma-separated list, dropping empty entries.
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseEscalationRoles parses "TYPE=roleID" pairs separated by commas, e.g.
+// "EARTHQUAKE=123,FLOOD=456,*=789", where "*" sets the default role.
+func parseEscalationRoles(s string, errs *ValidationError) map[disastersv1.DisasterType]string {
+	roles := make(map[disastersv1.DisasterType]string)
+	for _, pair := range parseList(s) {
+		name, roleID, ok := strings.Cut(pair, "=")
+		if !ok || roleID == "" {
+			errs.add("ESCALATION_ROLES", "%q is not TYPE=roleID", pair)
+			continue
+		}
+
+		if t, ok := parseTypeName(name); ok {
+			roles[t] = roleID
+		} else {
+			errs.add("ESCALATION_ROLES", "unknown disaster type %q", name)
+		}
+	}
+	return roles
+}
+
+// parsePopulationMultiples parses "TYPE=multiple" pairs separated by commas,
+// e.g. "FLOOD=2,EARTHQUAKE=5,*=3", where "*" sets the default multiple.
+func parsePopulationMultiples(s string, errs *ValidationError) map[disastersv1.DisasterType]float64 {
+	mults := make(map[disastersv1.DisasterType]float64)
+	for _, pair := range parseList(s) {
+		name, val, ok := strings.Cut(pair, "=")
+		mult, err := strconv.ParseFloat(val, 64)
+		if !ok || err != nil {
+			errs.add("ESCALATION_POPULATION_MULTIPLES", "%q is not TYPE=multiple", pair)
+			continue
+		}
+
+		if t, ok := parseTypeName(name); ok {
+			mults[t] = mult
+		} else {
+			errs.add("ESCALATION_POPULATION_MULTIPLES", "unknown disaster type %q", name)
+		}
+	}
+	return mults
 }
 
-func getEnvOrDefault(key, defaultVal string) string {
-	if val := os.Getenv(key); val != "" {
-		return val
+// PopulationMultiple returns the population multiple that escalates a
+// disaster of type t: its entry in EscalationPopulationMultiples, else the
+// "*" entry, else EscalationPopulationMultiple.
+func (cfg *Config) PopulationMultiple(t disastersv1.DisasterType) float64 {
+	if mult, ok := cfg.EscalationPopulationMultiples[t]; ok {
+		return mult
+	}
+	if mult, ok := cfg.EscalationPopulationMultiples[disastersv1.DisasterType_UNSPECIFIED]; ok {
+		return mult
 	}
-	return defaultVal
+	return cfg.EscalationPopulationMultiple
 }
@@ -1,22 +1,38 @@
 package config
 
 import (
+	"errors"
+	"maps"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/rules"
 )
 
-func TestLoad_Defaults(t *testing.T) {
+// clearEnv leaves only the required settings in the environment.
+func clearEnv() {
 	os.Clearenv()
+	os.Setenv("DISCORD_TOKEN", "test-token")
+	os.Setenv("DISCORD_CHANNEL_ID", "123456")
+}
 
-	cfg, err := Load()
+func TestLoad_Defaults(t *testing.T) {
+	clearEnv()
+
+	cfg, err := Load("")
 	if err != nil {
 		t.Fatalf("Load() error = %v", err)
 	}
 
-	if cfg.GRPCAddress != "localhost:50051" {
-		t.Errorf("GRPCAddress = %q, want %q", cfg.GRPCAddress, "localhost:50051")
+	if !slices.Equal(cfg.GRPCAddresses, []string{"localhost:50051"}) {
+		t.Errorf("GRPCAddresses = %q, want [localhost:50051]", cfg.GRPCAddresses)
 	}
 	if cfg.MinMagnitude != 5.0 {
 		t.Errorf("MinMagnitude = %v, want 5.0", cfg.MinMagnitude)
@@ -24,17 +40,103 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.AlertLevel != disastersv1.AlertLevel_ORANGE {
 		t.Errorf("AlertLevel = %v, want ORANGE", cfg.AlertLevel)
 	}
+	if cfg.MinPopulation != 500000 {
+		t.Errorf("MinPopulation = %d, want 500000", cfg.MinPopulation)
+	}
+	if len(cfg.Thresholds) != 0 {
+		t.Errorf("Thresholds = %v, want none", cfg.Thresholds)
+	}
+	if !cfg.EphemeralReplies {
+		t.Error("EphemeralReplies = false, want true")
+	}
+	if cfg.MessageFormat != MessageFormatEmbed {
+		t.Errorf("MessageFormat = %q, want %q", cfg.MessageFormat, MessageFormatEmbed)
+	}
+	if cfg.StorePath != "state.db" {
+		t.Errorf("StorePath = %q, want %q", cfg.StorePath, "state.db")
+	}
+	if len(cfg.EscalationRoles) != 0 {
+		t.Errorf("EscalationRoles = %v, want empty", cfg.EscalationRoles)
+	}
+	if cfg.EscalationPopulationMultiple != 2.0 {
+		t.Errorf("EscalationPopulationMultiple = %v, want 2.0", cfg.EscalationPopulationMultiple)
+	}
+	if cfg.ReconnectInitialInterval != time.Second || cfg.ReconnectMaxInterval != time.Minute {
+		t.Errorf("Reconnect intervals = %v..%v, want 1s..1m", cfg.ReconnectInitialInterval, cfg.ReconnectMaxInterval)
+	}
+	if cfg.ReconnectMultiplier != 2.0 || cfg.ReconnectJitter != 0.2 {
+		t.Errorf("ReconnectMultiplier, ReconnectJitter = %v, %v, want 2.0, 0.2", cfg.ReconnectMultiplier, cfg.ReconnectJitter)
+	}
+	if cfg.ReconnectMaxRetries != 5 {
+		t.Errorf("ReconnectMaxRetries = %d, want 5", cfg.ReconnectMaxRetries)
+	}
+	if cfg.BackfillWindow != 24*time.Hour || cfg.BackfillPageSize != 50 {
+		t.Errorf("BackfillWindow, BackfillPageSize = %v, %d, want 24h, 50", cfg.BackfillWindow, cfg.BackfillPageSize)
+	}
+	if cfg.GRPCTLS {
+		t.Error("GRPCTLS = true, want false")
+	}
+	if cfg.GRPCAuthToken != "" || cfg.GRPCAuthTokenFile != "" || cfg.GRPCAuthAllowInsecure {
+		t.Errorf("GRPC auth = %q, %q, %v, want unset", cfg.GRPCAuthToken, cfg.GRPCAuthTokenFile, cfg.GRPCAuthAllowInsecure)
+	}
+	if cfg.HTTPAddress != ":9090" {
+		t.Errorf("HTTPAddress = %q, want %q", cfg.HTTPAddress, ":9090")
+	}
+	if cfg.GRPCHealthCheckInterval != 10*time.Second {
+		t.Errorf("GRPCHealthCheckInterval = %v, want 10s", cfg.GRPCHealthCheckInterval)
+	}
+	if cfg.GRPCKeepaliveTime != 5*time.Minute || cfg.GRPCKeepaliveTimeout != 20*time.Second {
+		t.Errorf("GRPC keepalive = %v/%v, want 5m/20s", cfg.GRPCKeepaliveTime, cfg.GRPCKeepaliveTimeout)
+	}
+	if cfg.StreamIdleTimeout != 0 {
+		t.Errorf("StreamIdleTimeout = %v, want 0 (disabled)", cfg.StreamIdleTimeout)
+	}
+	if cfg.TracingExporter != "" {
+		t.Errorf("TracingExporter = %q, want disabled", cfg.TracingExporter)
+	}
+	if cfg.HealthStreamTimeout != 5*time.Minute {
+		t.Errorf("HealthStreamTimeout = %v, want 5m", cfg.HealthStreamTimeout)
+	}
 }
 
 func TestLoad_EnvVars(t *testing.T) {
-	os.Clearenv()
+	clearEnv()
 	os.Setenv("DISCORD_TOKEN", "test-token")
 	os.Setenv("DISCORD_CHANNEL_ID", "123456")
-	os.Setenv("GRPC_ADDRESS", "localhost:9000")
+	os.Setenv("GRPC_ADDRESS", "localhost:9000, backup:9000,")
 	os.Setenv("MIN_MAGNITUDE", "6.0")
 	os.Setenv("ALERT_LEVEL", "RED")
+	os.Setenv("MIN_POPULATION", "1000000")
+	os.Setenv("DISCORD_GUILD_ID", "789")
+	os.Setenv("EPHEMERAL_REPLIES", "false")
+	os.Setenv("MESSAGE_FORMAT", "text")
+	os.Setenv("STORE_PATH", "/data/state.db")
+	os.Setenv("ESCALATION_ROLES", "EARTHQUAKE=111, *=222,")
+	os.Setenv("ESCALATION_POPULATION_MULTIPLE", "3")
+	os.Setenv("ESCALATION_POPULATION_MULTIPLES", "EARTHQUAKE=5, *=4")
+	os.Setenv("RECONNECT_INITIAL_INTERVAL", "500ms")
+	os.Setenv("RECONNECT_MAX_INTERVAL", "5m")
+	os.Setenv("RECONNECT_MULTIPLIER", "1.5")
+	os.Setenv("RECONNECT_JITTER", "0")
+	os.Setenv("RECONNECT_MAX_RETRIES", "0")
+	os.Setenv("BACKFILL_WINDOW", "72h")
+	os.Setenv("BACKFILL_PAGE_SIZE", "200")
+	os.Setenv("GRPC_TLS_CA_FILE", "/certs/ca.pem")
+	os.Setenv("GRPC_TLS_CERT_FILE", "/certs/client.pem")
+	os.Setenv("GRPC_TLS_KEY_FILE", "/certs/client-key.pem")
+	os.Setenv("GRPC_TLS_SERVER_NAME", "alerts.internal")
+	os.Setenv("GRPC_AUTH_TOKEN", "secret")
+	os.Setenv("GRPC_AUTH_TOKEN_FILE", "/run/secrets/token")
+	os.Setenv("GRPC_AUTH_ALLOW_INSECURE", "true")
+	os.Setenv("GRPC_HEALTH_CHECK_INTERVAL", "30s")
+	os.Setenv("HTTP_ADDRESS", "")
+	os.Setenv("GRPC_KEEPALIVE_TIME", "0")
+	os.Setenv("GRPC_KEEPALIVE_TIMEOUT", "5s")
+	os.Setenv("STREAM_IDLE_TIMEOUT", "2h")
+	os.Setenv("HEALTH_STREAM_TIMEOUT", "90s")
+	os.Setenv("TRACING_EXPORTER", "otlp")
 
-	cfg, err := Load()
+	cfg, err := Load("")
 	if err != nil {
 		t.Fatalf("Load() error = %v", err)
 	}
@@ -45,8 +147,8 @@ func TestLoad_EnvVars(t *testing.T) {
 	if cfg.ChannelID != "123456" {
 		t.Errorf("ChannelID = %q, want %q", cfg.ChannelID, "123456")
 	}
-	if cfg.GRPCAddress != "localhost:9000" {
-		t.Errorf("GRPCAddress = %q, want %q", cfg.GRPCAddress, "localhost:9000")
+	if !slices.Equal(cfg.GRPCAddresses, []string{"localhost:9000", "backup:9000"}) {
+		t.Errorf("GRPCAddresses = %q, want [localhost:9000 backup:9000]", cfg.GRPCAddresses)
 	}
 	if cfg.MinMagnitude != 6.0 {
 		t.Errorf("MinMagnitude = %v, want 6.0", cfg.MinMagnitude)
@@ -54,4 +156,395 @@ func TestLoad_EnvVars(t *testing.T) {
 	if cfg.AlertLevel != disastersv1.AlertLevel_RED {
 		t.Errorf("AlertLevel = %v, want RED", cfg.AlertLevel)
 	}
+	if cfg.MinPopulation != 1000000 {
+		t.Errorf("MinPopulation = %d, want 1000000", cfg.MinPopulation)
+	}
+	if cfg.GuildID != "789" {
+		t.Errorf("GuildID = %q, want %q", cfg.GuildID, "789")
+	}
+	if cfg.EphemeralReplies {
+		t.Error("EphemeralReplies = true, want false")
+	}
+	if cfg.MessageFormat != MessageFormatText {
+		t.Errorf("MessageFormat = %q, want %q", cfg.MessageFormat, MessageFormatText)
+	}
+	if cfg.StorePath != "/data/state.db" {
+		t.Errorf("StorePath = %q, want %q", cfg.StorePath, "/data/state.db")
+	}
+	wantRoles := map[disastersv1.DisasterType]string{
+		disastersv1.DisasterType_EARTHQUAKE:  "111",
+		disastersv1.DisasterType_UNSPECIFIED: "222",
+	}
+	if !maps.Equal(cfg.EscalationRoles, wantRoles) {
+		t.Errorf("EscalationRoles = %v, want %v", cfg.EscalationRoles, wantRoles)
+	}
+	if cfg.EscalationPopulationMultiple != 3 {
+		t.Errorf("EscalationPopulationMultiple = %v, want 3", cfg.EscalationPopulationMultiple)
+	}
+	wantMults := map[disastersv1.DisasterType]float64{
+		disastersv1.DisasterType_EARTHQUAKE:  5,
+		disastersv1.DisasterType_UNSPECIFIED: 4,
+	}
+	if !maps.Equal(cfg.EscalationPopulationMultiples, wantMults) {
+		t.Errorf("EscalationPopulationMultiples = %v, want %v", cfg.EscalationPopulationMultiples, wantMults)
+	}
+	if cfg.ReconnectInitialInterval != 500*time.Millisecond || cfg.ReconnectMaxInterval != 5*time.Minute {
+		t.Errorf("Reconnect intervals = %v..%v, want 500ms..5m", cfg.ReconnectInitialInterval, cfg.ReconnectMaxInterval)
+	}
+	if cfg.ReconnectMultiplier != 1.5 || cfg.ReconnectJitter != 0 {
+		t.Errorf("ReconnectMultiplier, ReconnectJitter = %v, %v, want 1.5, 0", cfg.ReconnectMultiplier, cfg.ReconnectJitter)
+	}
+	if cfg.ReconnectMaxRetries != 0 {
+		t.Errorf("ReconnectMaxRetries = %d, want 0 (unlimited)", cfg.ReconnectMaxRetries)
+	}
+	if cfg.BackfillWindow != 72*time.Hour || cfg.BackfillPageSize != 200 {
+		t.Errorf("BackfillWindow, BackfillPageSize = %v, %d, want 72h, 200", cfg.BackfillWindow, cfg.BackfillPageSize)
+	}
+	if !cfg.GRPCTLS {
+		t.Error("GRPCTLS = false, want true when a CA file is set")
+	}
+	if cfg.GRPCCAFile != "/certs/ca.pem" || cfg.GRPCCertFile != "/certs/client.pem" || cfg.GRPCKeyFile != "/certs/client-key.pem" {
+		t.Errorf("GRPC TLS files = %q, %q, %q", cfg.GRPCCAFile, cfg.GRPCCertFile, cfg.GRPCKeyFile)
+	}
+	if cfg.GRPCServerName != "alerts.internal" {
+		t.Errorf("GRPCServerName = %q, want %q", cfg.GRPCServerName, "alerts.internal")
+	}
+	if cfg.GRPCAuthToken != "secret" || cfg.GRPCAuthTokenFile != "/run/secrets/token" || !cfg.GRPCAuthAllowInsecure {
+		t.Errorf("GRPC auth = %q, %q, %v, want secret, /run/secrets/token, true", cfg.GRPCAuthToken, cfg.GRPCAuthTokenFile, cfg.GRPCAuthAllowInsecure)
+	}
+	if cfg.HTTPAddress != "" {
+		t.Errorf("HTTPAddress = %q, want empty (disabled)", cfg.HTTPAddress)
+	}
+	if cfg.GRPCHealthCheckInterval != 30*time.Second {
+		t.Errorf("GRPCHealthCheckInterval = %v, want 30s", cfg.GRPCHealthCheckInterval)
+	}
+	if cfg.GRPCKeepaliveTime != 0 || cfg.GRPCKeepaliveTimeout != 5*time.Second {
+		t.Errorf("GRPC keepalive = %v/%v, want 0 (disabled)/5s", cfg.GRPCKeepaliveTime, cfg.GRPCKeepaliveTimeout)
+	}
+	if cfg.StreamIdleTimeout != 2*time.Hour {
+		t.Errorf("StreamIdleTimeout = %v, want 2h", cfg.StreamIdleTimeout)
+	}
+	if cfg.TracingExporter != "otlp" {
+		t.Errorf("TracingExporter = %q, want otlp", cfg.TracingExporter)
+	}
+	if cfg.HealthStreamTimeout != 90*time.Second {
+		t.Errorf("HealthStreamTimeout = %v, want 90s", cfg.HealthStreamTimeout)
+	}
+}
+
+func TestLoad_GRPCTLS(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"disabled by default", nil, false},
+		{"enabled explicitly", map[string]string{"GRPC_TLS": "true"}, true},
+		{"implied by CA file", map[string]string{"GRPC_TLS_CA_FILE": "ca.pem"}, true},
+		{"implied by client cert", map[string]string{"GRPC_TLS_CERT_FILE": "c.pem", "GRPC_TLS_KEY_FILE": "k.pem"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+
+			cfg, err := Load("")
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.GRPCTLS != tt.want {
+				t.Errorf("GRPCTLS = %v, want %v", cfg.GRPCTLS, tt.want)
+			}
+		})
+	}
+}
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_File(t *testing.T) {
+	clearEnv()
+	os.Unsetenv("DISCORD_TOKEN")
+	os.Setenv("MIN_MAGNITUDE", "6.5")
+	os.Setenv("GRPC_AUTH_TOKEN", "from-env")
+
+	path := writeConfig(t, `
+discord:
+  token: file-token
+  channel_id: "123456"
+  message_format: text
+filter:
+  min_magnitude: 4.5
+  alert_level: RED
+  thresholds:
+    FLOOD:
+      alert_level: ORANGE
+      min_population: 1000000
+      match: any
+    "*":
+      min_magnitude: 6
+escalation:
+  roles:
+    EARTHQUAKE: "111"
+    "*": "222"
+  population_multiples:
+    FLOOD: 3
+grpc:
+  addresses: [primary:50051, backup:50051]
+  tls:
+    ca_file: /certs/ca.pem
+  auth:
+    token: file-secret
+  stream_idle_timeout: 1h
+reconnect:
+  max_retries: 0
+http:
+  address: ""
+store_path: /data/state.db
+routes:
+  - channel_id: "789"
+    rule: type == FLOOD && population > 1000000 || alert_level == RED
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Token != "file-token" || cfg.ChannelID != "123456" {
+		t.Errorf("Token, ChannelID = %q, %q, want file-token, 123456", cfg.Token, cfg.ChannelID)
+	}
+	if cfg.MessageFormat != MessageFormatText {
+		t.Errorf("MessageFormat = %q, want %q", cfg.MessageFormat, MessageFormatText)
+	}
+	if cfg.MinMagnitude != 6.5 {
+		t.Errorf("MinMagnitude = %v, want 6.5 from the environment", cfg.MinMagnitude)
+	}
+	if cfg.AlertLevel != disastersv1.AlertLevel_RED {
+		t.Errorf("AlertLevel = %v, want RED", cfg.AlertLevel)
+	}
+	wantThresholds := map[disastersv1.DisasterType]Threshold{
+		disastersv1.DisasterType_FLOOD:       {AlertLevel: disastersv1.AlertLevel_ORANGE, MinPopulation: 1000000, Match: MatchAny},
+		disastersv1.DisasterType_UNSPECIFIED: {MinMagnitude: 6, Match: MatchAll},
+	}
+	if !maps.Equal(cfg.Thresholds, wantThresholds) {
+		t.Errorf("Thresholds = %v, want %v", cfg.Thresholds, wantThresholds)
+	}
+	wantRoutes := []string{"789: type == FLOOD && population > 1000000 || alert_level == RED"}
+	if got := routeStrings(cfg.Routes); !slices.Equal(got, wantRoutes) {
+		t.Errorf("Routes = %q, want %q", got, wantRoutes)
+	}
+	wantRoles := map[disastersv1.DisasterType]string{
+		disastersv1.DisasterType_EARTHQUAKE:  "111",
+		disastersv1.DisasterType_UNSPECIFIED: "222",
+	}
+	if !maps.Equal(cfg.EscalationRoles, wantRoles) {
+		t.Errorf("EscalationRoles = %v, want %v", cfg.EscalationRoles, wantRoles)
+	}
+	if got := cfg.PopulationMultiple(disastersv1.DisasterType_FLOOD); got != 3 {
+		t.Errorf("PopulationMultiple(FLOOD) = %v, want 3", got)
+	}
+	if got := cfg.PopulationMultiple(disastersv1.DisasterType_EARTHQUAKE); got != 2 {
+		t.Errorf("PopulationMultiple(EARTHQUAKE) = %v, want the default 2", got)
+	}
+	if !slices.Equal(cfg.GRPCAddresses, []string{"primary:50051", "backup:50051"}) {
+		t.Errorf("GRPCAddresses = %q, want [primary:50051 backup:50051]", cfg.GRPCAddresses)
+	}
+	if !cfg.GRPCTLS {
+		t.Error("GRPCTLS = false, want true when a CA file is set")
+	}
+	if cfg.GRPCAuthToken != "from-env" {
+		t.Errorf("GRPCAuthToken = %q, want from-env", cfg.GRPCAuthToken)
+	}
+	if cfg.StreamIdleTimeout != time.Hour {
+		t.Errorf("StreamIdleTimeout = %v, want 1h", cfg.StreamIdleTimeout)
+	}
+	if cfg.ReconnectMaxRetries != 0 {
+		t.Errorf("ReconnectMaxRetries = %d, want 0", cfg.ReconnectMaxRetries)
+	}
+	if cfg.HTTPAddress != "" {
+		t.Errorf("HTTPAddress = %q, want empty (disabled)", cfg.HTTPAddress)
+	}
+	if cfg.StorePath != "/data/state.db" {
+		t.Errorf("StorePath = %q, want /data/state.db", cfg.StorePath)
+	}
+
+	// Keys missing from the file keep their defaults
+	if cfg.ReconnectInitialInterval != time.Second || cfg.BackfillWindow != 24*time.Hour {
+		t.Errorf("ReconnectInitialInterval, BackfillWindow = %v, %v, want defaults", cfg.ReconnectInitialInterval, cfg.BackfillWindow)
+	}
+	if cfg.SubscriptionsPath != "subscriptions.json" || !cfg.EphemeralReplies {
+		t.Errorf("SubscriptionsPath, EphemeralReplies = %q, %v, want defaults", cfg.SubscriptionsPath, cfg.EphemeralReplies)
+	}
+}
+
+func TestLoad_FileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"unknown key", "filter:\n  min_magnitud: 5\n"},
+		{"wrong type", "reconnect:\n  max_retries: lots\n"},
+		{"bare number duration", "backfill:\n  window: 3600\n"},
+		{"unknown alert level", "filter:\n  alert_level: PURPLE\n"},
+		{"unknown message format", "discord:\n  message_format: html\n"},
+		{"unknown tracing exporter", "tracing:\n  exporter: jaeger\n"},
+		{"unknown escalation type", "escalation:\n  roles:\n    METEOR: \"1\"\n"},
+		{"unknown threshold type", "filter:\n  thresholds:\n    METEOR:\n      alert_level: RED\n"},
+		{"unknown threshold key", "filter:\n  thresholds:\n    FLOOD:\n      level: RED\n"},
+		{"invalid route rule", "routes:\n  - channel_id: \"1\"\n    rule: type ==\n"},
+		{"route rule not a bool", "routes:\n  - channel_id: \"1\"\n    rule: magnitude\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv()
+			if _, err := Load(writeConfig(t, tt.contents)); err == nil {
+				t.Error("Load() error = nil, want an error")
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		clearEnv()
+		if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Error("Load() error = nil, want an error")
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		clearEnv()
+		cfg, err := Load(writeConfig(t, ""))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.AlertLevel != disastersv1.AlertLevel_ORANGE {
+			t.Errorf("AlertLevel = %v, want the ORANGE default", cfg.AlertLevel)
+		}
+	})
+}
+
+func TestLoadUpstream(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("GRPC_ADDRESS", "primary:50051")
+	os.Setenv("MIN_MAGNITUDE", "11")
+
+	cfg, err := LoadUpstream("")
+	if err != nil {
+		t.Fatalf("LoadUpstream() without Discord settings error = %v", err)
+	}
+	if !slices.Equal(cfg.GRPCAddresses, []string{"primary:50051"}) {
+		t.Errorf("GRPCAddresses = %q, want [primary:50051]", cfg.GRPCAddresses)
+	}
+
+	os.Setenv("GRPC_ADDRESS", "no-port")
+	_, err = LoadUpstream("")
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Field != "GRPC_ADDRESS" {
+		t.Errorf("LoadUpstream() with a bad address error = %v, want only GRPC_ADDRESS", err)
+	}
+}
+
+func TestConfig_MarshalRoundTrip(t *testing.T) {
+	clearEnv()
+	os.Setenv("DISCORD_TOKEN", "test-token")
+	os.Setenv("GRPC_ADDRESS", "primary:50051,backup:50051")
+	os.Setenv("ALERT_LEVEL", "RED")
+	os.Setenv("ESCALATION_ROLES", "FLOOD=111,*=222")
+	os.Setenv("GRPC_KEEPALIVE_TIME", "0")
+	os.Setenv("BACKFILL_WINDOW", "72h")
+
+	want, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want.Thresholds = map[disastersv1.DisasterType]Threshold{
+		disastersv1.DisasterType_FLOOD:       {AlertLevel: disastersv1.AlertLevel_RED, MinPopulation: 1000000, Match: MatchAny},
+		disastersv1.DisasterType_UNSPECIFIED: {MinMagnitude: 6, Match: MatchAll},
+	}
+	want.Routes = []Route{{ChannelID: "789", Rule: mustCompile(t, `title.contains("Nepal") && alert_level >= ORANGE`)}}
+	data, err := want.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	clearEnv()
+	got, err := Load(writeConfig(t, string(data)))
+	if err != nil {
+		t.Fatalf("Load() of marshaled config error = %v\n%s", err, data)
+	}
+	// Compiled rules only compare by their source
+	if gotRoutes, wantRoutes := routeStrings(got.Routes), routeStrings(want.Routes); !slices.Equal(gotRoutes, wantRoutes) {
+		t.Errorf("round trip Routes = %q, want %q", gotRoutes, wantRoutes)
+	}
+	got.Routes, want.Routes = nil, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := &Config{Token: "discord-secret", GRPCAuthToken: "grpc-secret", GRPCAuthTokenFile: "/run/secrets/token"}
+
+	data, err := cfg.Redacted().Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	out := string(data)
+	for _, secret := range []string{"discord-secret", "grpc-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("redacted config contains %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "/run/secrets/token") {
+		t.Errorf("redacted config lost the token file path:\n%s", out)
+	}
+	if cfg.Token != "discord-secret" {
+		t.Error("Redacted() modified the original config")
+	}
+
+	if empty := (&Config{}).Redacted(); empty.Token != "" || empty.GRPCAuthToken != "" {
+		t.Errorf("Redacted() of unset secrets = %q, %q, want them left empty", empty.Token, empty.GRPCAuthToken)
+	}
+}
+
+func TestLoad_ExampleFile(t *testing.T) {
+	clearEnv()
+
+	cfg, err := Load(filepath.Join("..", "..", "config.example.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := defaults()
+	want.Token, want.ChannelID = "test-token", "123456"
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("config.example.yaml = %+v, want the defaults", cfg)
+	}
+}
+
+func mustCompile(t *testing.T, expr string) *rules.Rule {
+	t.Helper()
+	rule, err := rules.Compile(expr)
+	if err != nil {
+		t.Fatalf("Compile(%q) error = %v", expr, err)
+	}
+	return rule
+}
+
+// routeStrings renders routes as "channel: rule" for comparison.
+func routeStrings(routes []Route) []string {
+	out := make([]string, len(routes))
+	for i, r := range routes {
+		out[i] = r.ChannelID + ": " + r.Rule.String()
+	}
+	return out
 }
@@ -0,0 +1,378 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/rules"
+)
+
+// redacted replaces secrets in Redacted configs.
+const redacted = "REDACTED"
+
+// fileConfig is the schema of the YAML config file. Every key is optional and
+// unknown keys are rejected. Environment variables override the file.
+type fileConfig struct {
+	Discord           discordFile    `yaml:"discord"`
+	Filter            filterFile     `yaml:"filter"`
+	Escalation        escalationFile `yaml:"escalation"`
+	GRPC              grpcFile       `yaml:"grpc"`
+	Reconnect         reconnectFile  `yaml:"reconnect"`
+	Backfill          backfillFile   `yaml:"backfill"`
+	HTTP              httpFile       `yaml:"http"`
+	Tracing           tracingFile    `yaml:"tracing"`
+	Routes            []routeFile    `yaml:"routes"`
+	SubscriptionsPath string         `yaml:"subscriptions_path"`
+	GeofencesPath     string         `yaml:"geofences_path"`
+	StorePath         string         `yaml:"store_path"`
+}
+
+type discordFile struct {
+	Token            string `yaml:"token"`
+	ChannelID        string `yaml:"channel_id"`
+	GuildID          string `yaml:"guild_id"`
+	EphemeralReplies bool   `yaml:"ephemeral_replies"`
+	MessageFormat    string `yaml:"message_format"`
+}
+
+type filterFile struct {
+	MinMagnitude  float64 `yaml:"min_magnitude"`
+	AlertLevel    string  `yaml:"alert_level"`
+	MinPopulation int64   `yaml:"min_population"`
+
+	// Thresholds maps a disaster type name, or "*" for the default, to its
+	// filter.
+	Thresholds map[string]thresholdFile `yaml:"thresholds"`
+}
+
+type thresholdFile struct {
+	AlertLevel    string  `yaml:"alert_level"`
+	MinMagnitude  float64 `yaml:"min_magnitude"`
+	MinPopulation int64   `yaml:"min_population"`
+	Match         string  `yaml:"match"`
+}
+
+type routeFile struct {
+	ChannelID string `yaml:"channel_id"`
+	Rule      string `yaml:"rule"`
+}
+
+type escalationFile struct {
+	// Roles maps a disaster type name, or "*" for the default, to a role ID.
+	Roles map[string]string `yaml:"roles"`
+	// PopulationMultiples overrides PopulationMultiple per disaster type
+	// name, or "*" for the default.
+	PopulationMultiples map[string]float64 `yaml:"population_multiples"`
+	PopulationMultiple  float64            `yaml:"population_multiple"`
+}
+
+type grpcFile struct {
+	Addresses           []string      `yaml:"addresses"`
+	TLS                 grpcTLSFile   `yaml:"tls"`
+	Auth                grpcAuthFile  `yaml:"auth"`
+	HealthCheckInterval time.Duration `yaml:"health_check_interval"`
+	KeepaliveTime       time.Duration `yaml:"keepalive_time"`
+	KeepaliveTimeout    time.Duration `yaml:"keepalive_timeout"`
+	StreamIdleTimeout   time.Duration `yaml:"stream_idle_timeout"`
+}
+
+type grpcTLSFile struct {
+	Enabled    bool   `yaml:"enabled"`
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+}
+
+type grpcAuthFile struct {
+	Token         string `yaml:"token"`
+	TokenFile     string `yaml:"token_file"`
+	AllowInsecure bool   `yaml:"allow_insecure"`
+}
+
+type reconnectFile struct {
+	InitialInterval time.Duration `yaml:"initial_interval"`
+	MaxInterval     time.Duration `yaml:"max_interval"`
+	Multiplier      float64       `yaml:"multiplier"`
+	Jitter          float64       `yaml:"jitter"`
+	MaxRetries      int           `yaml:"max_retries"`
+}
+
+type backfillFile struct {
+	Window   time.Duration `yaml:"window"`
+	PageSize int32         `yaml:"page_size"`
+}
+
+type httpFile struct {
+	Address             string        `yaml:"address"`
+	HealthStreamTimeout time.Duration `yaml:"health_stream_timeout"`
+}
+
+type tracingFile struct {
+	Exporter string `yaml:"exporter"`
+}
+
+// loadFile overrides cfg with the keys set in the YAML file at path. Names
+// that don't resolve are added to errs; anything else that doesn't parse
+// fails the whole file.
+func (cfg *Config) loadFile(path string, errs *ValidationError) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Decoding over the current values leaves keys missing from the file alone
+	f := toFile(cfg)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	f.apply(cfg, errs)
+	return nil
+}
+
+// Marshal returns cfg in the config file format.
+func (cfg *Config) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(toFile(cfg)); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Redacted returns a copy of cfg with secrets replaced, for display.
+func (cfg *Config) Redacted() *Config {
+	c := *cfg
+	for _, secret := range []*string{&c.Token, &c.GRPCAuthToken} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return &c
+}
+
+func toFile(cfg *Config) *fileConfig {
+	roles := make(map[string]string, len(cfg.EscalationRoles))
+	for t, roleID := range cfg.EscalationRoles {
+		roles[typeName(t)] = roleID
+	}
+
+	mults := make(map[string]float64, len(cfg.EscalationPopulationMultiples))
+	for t, mult := range cfg.EscalationPopulationMultiples {
+		mults[typeName(t)] = mult
+	}
+
+	thresholds := make(map[string]thresholdFile, len(cfg.Thresholds))
+	for t, th := range cfg.Thresholds {
+		tf := thresholdFile{MinMagnitude: th.MinMagnitude, MinPopulation: th.MinPopulation, Match: th.Match}
+		if th.AlertLevel != disastersv1.AlertLevel_UNKNOWN {
+			tf.AlertLevel = th.AlertLevel.String()
+		}
+		thresholds[typeName(t)] = tf
+	}
+
+	routes := make([]routeFile, len(cfg.Routes))
+	for i, r := range cfg.Routes {
+		routes[i] = routeFile{ChannelID: r.ChannelID, Rule: r.Rule.String()}
+	}
+
+	return &fileConfig{
+		Discord: discordFile{
+			Token:            cfg.Token,
+			ChannelID:        cfg.ChannelID,
+			GuildID:          cfg.GuildID,
+			EphemeralReplies: cfg.EphemeralReplies,
+			MessageFormat:    cfg.MessageFormat,
+		},
+		Filter: filterFile{
+			MinMagnitude:  cfg.MinMagnitude,
+			AlertLevel:    cfg.AlertLevel.String(),
+			MinPopulation: cfg.MinPopulation,
+			Thresholds:    thresholds,
+		},
+		Escalation: escalationFile{
+			Roles:               roles,
+			PopulationMultiples: mults,
+			PopulationMultiple:  cfg.EscalationPopulationMultiple,
+		},
+		GRPC: grpcFile{
+			Addresses: slices.Clone(cfg.GRPCAddresses),
+			TLS: grpcTLSFile{
+				Enabled:    cfg.GRPCTLS,
+				CAFile:     cfg.GRPCCAFile,
+				CertFile:   cfg.GRPCCertFile,
+				KeyFile:    cfg.GRPCKeyFile,
+				ServerName: cfg.GRPCServerName,
+			},
+			Auth: grpcAuthFile{
+				Token:         cfg.GRPCAuthToken,
+				TokenFile:     cfg.GRPCAuthTokenFile,
+				AllowInsecure: cfg.GRPCAuthAllowInsecure,
+			},
+			HealthCheckInterval: cfg.GRPCHealthCheckInterval,
+			KeepaliveTime:       cfg.GRPCKeepaliveTime,
+			KeepaliveTimeout:    cfg.GRPCKeepaliveTimeout,
+			StreamIdleTimeout:   cfg.StreamIdleTimeout,
+		},
+		Reconnect: reconnectFile{
+			InitialInterval: cfg.ReconnectInitialInterval,
+			MaxInterval:     cfg.ReconnectMaxInterval,
+			Multiplier:      cfg.ReconnectMultiplier,
+			Jitter:          cfg.ReconnectJitter,
+			MaxRetries:      cfg.ReconnectMaxRetries,
+		},
+		Backfill: backfillFile{
+			Window:   cfg.BackfillWindow,
+			PageSize: cfg.BackfillPageSize,
+		},
+		HTTP: httpFile{
+			Address:             cfg.HTTPAddress,
+			HealthStreamTimeout: cfg.HealthStreamTimeout,
+		},
+		Tracing: tracingFile{
+			Exporter: cfg.TracingExporter,
+		},
+		Routes:            routes,
+		SubscriptionsPath: cfg.SubscriptionsPath,
+		GeofencesPath:     cfg.GeofencesPath,
+		StorePath:         cfg.StorePath,
+	}
+}
+
+// apply copies f into cfg, resolving the names used in the file.
+func (f *fileConfig) apply(cfg *Config, errs *ValidationError) {
+	if val, ok := disastersv1.AlertLevel_value[f.Filter.AlertLevel]; ok {
+		cfg.AlertLevel = disastersv1.AlertLevel(val)
+	} else {
+		errs.add("filter.alert_level", "unknown alert level %q", f.Filter.AlertLevel)
+	}
+
+	thresholds := make(map[disastersv1.DisasterType]Threshold, len(f.Filter.Thresholds))
+	for _, name := range slices.Sorted(maps.Keys(f.Filter.Thresholds)) {
+		tf := f.Filter.Thresholds[name]
+		t, ok := parseTypeName(name)
+		if !ok {
+			errs.add("filter.thresholds", "unknown disaster type %q", name)
+			continue
+		}
+
+		th := Threshold{MinMagnitude: tf.MinMagnitude, MinPopulation: tf.MinPopulation, Match: tf.Match}
+		if th.Match == "" {
+			th.Match = MatchAll
+		}
+		if tf.AlertLevel != "" {
+			if val, ok := disastersv1.AlertLevel_value[tf.AlertLevel]; ok {
+				th.AlertLevel = disastersv1.AlertLevel(val)
+			} else {
+				errs.add("filter.thresholds."+name+".alert_level", "unknown alert level %q", tf.AlertLevel)
+			}
+		}
+		thresholds[t] = th
+	}
+
+	roles := make(map[disastersv1.DisasterType]string, len(f.Escalation.Roles))
+	for _, name := range slices.Sorted(maps.Keys(f.Escalation.Roles)) {
+		if t, ok := parseTypeName(name); ok {
+			roles[t] = f.Escalation.Roles[name]
+		} else {
+			errs.add("escalation.roles", "unknown disaster type %q", name)
+		}
+	}
+
+	mults := make(map[disastersv1.DisasterType]float64, len(f.Escalation.PopulationMultiples))
+	for _, name := range slices.Sorted(maps.Keys(f.Escalation.PopulationMultiples)) {
+		if t, ok := parseTypeName(name); ok {
+			mults[t] = f.Escalation.PopulationMultiples[name]
+		} else {
+			errs.add("escalation.population_multiples", "unknown disaster type %q", name)
+		}
+	}
+
+	var routes []Route
+	for i, rf := range f.Routes {
+		rule, err := rules.Compile(rf.Rule)
+		if err != nil {
+			errs.add(fmt.Sprintf("routes[%d].rule", i), "%v", err)
+			continue
+		}
+		routes = append(routes, Route{ChannelID: rf.ChannelID, Rule: rule})
+	}
+
+	cfg.Token = f.Discord.Token
+	cfg.ChannelID = f.Discord.ChannelID
+	cfg.GuildID = f.Discord.GuildID
+	cfg.EphemeralReplies = f.Discord.EphemeralReplies
+	cfg.MessageFormat = f.Discord.MessageFormat
+
+	cfg.MinMagnitude = f.Filter.MinMagnitude
+	cfg.MinPopulation = f.Filter.MinPopulation
+	cfg.Thresholds = thresholds
+	cfg.Routes = routes
+
+	cfg.EscalationRoles = roles
+	cfg.EscalationPopulationMultiples = mults
+	cfg.EscalationPopulationMultiple = f.Escalation.PopulationMultiple
+
+	cfg.GRPCAddresses = f.GRPC.Addresses
+	cfg.GRPCTLS = f.GRPC.TLS.Enabled
+	cfg.GRPCCAFile = f.GRPC.TLS.CAFile
+	cfg.GRPCCertFile = f.GRPC.TLS.CertFile
+	cfg.GRPCKeyFile = f.GRPC.TLS.KeyFile
+	cfg.GRPCServerName = f.GRPC.TLS.ServerName
+	cfg.GRPCAuthToken = f.GRPC.Auth.Token
+	cfg.GRPCAuthTokenFile = f.GRPC.Auth.TokenFile
+	cfg.GRPCAuthAllowInsecure = f.GRPC.Auth.AllowInsecure
+	cfg.GRPCHealthCheckInterval = f.GRPC.HealthCheckInterval
+	cfg.GRPCKeepaliveTime = f.GRPC.KeepaliveTime
+	cfg.GRPCKeepaliveTimeout = f.GRPC.KeepaliveTimeout
+	cfg.StreamIdleTimeout = f.GRPC.StreamIdleTimeout
+
+	cfg.ReconnectInitialInterval = f.Reconnect.InitialInterval
+	cfg.ReconnectMaxInterval = f.Reconnect.MaxInterval
+	cfg.ReconnectMultiplier = f.Reconnect.Multiplier
+	cfg.ReconnectJitter = f.Reconnect.Jitter
+	cfg.ReconnectMaxRetries = f.Reconnect.MaxRetries
+
+	cfg.BackfillWindow = f.Backfill.Window
+	cfg.BackfillPageSize = f.Backfill.PageSize
+
+	cfg.HTTPAddress = f.HTTP.Address
+	cfg.HealthStreamTimeout = f.HTTP.HealthStreamTimeout
+
+	cfg.TracingExporter = f.Tracing.Exporter
+
+	cfg.SubscriptionsPath = f.SubscriptionsPath
+	cfg.GeofencesPath = f.GeofencesPath
+	cfg.StorePath = f.StorePath
+}
+
+// typeName returns the config file name of t, "*" for UNSPECIFIED.
+func typeName(t disastersv1.DisasterType) string {
+	if t == disastersv1.DisasterType_UNSPECIFIED {
+		return "*"
+	}
+	return t.String()
+}
+
+func parseTypeName(name string) (disastersv1.DisasterType, bool) {
+	if name == "*" {
+		return disastersv1.DisasterType_UNSPECIFIED, true
+	}
+	val, ok := disastersv1.DisasterType_value[name]
+	return disastersv1.DisasterType(val), ok
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// reloadable lists the config file keys, and sections ending in ".", that
+// Reload applies. Everything else needs a restart.
+var reloadable = []string{"discord.channel_id", "filter.", "escalation.", "routes"}
+
+// secrets are config file keys whose values are redacted in changes.
+var secrets = []string{"discord.token", "grpc.auth.token"}
+
+// Change is a setting that differs between two configs, by config file key.
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Reload returns a copy of cfg with the filtering and routing settings taken
+// from next. It also returns the changes that were applied, and those that
+// were ignored because they need a restart.
+func (cfg *Config) Reload(next *Config) (reloaded *Config, applied, ignored []Change) {
+	c := *cfg
+	c.ChannelID = next.ChannelID
+	c.MinMagnitude = next.MinMagnitude
+	c.AlertLevel = next.AlertLevel
+	c.MinPopulation = next.MinPopulation
+	c.Thresholds = next.Thresholds
+	c.EscalationRoles = next.EscalationRoles
+	c.EscalationPopulationMultiples = next.EscalationPopulationMultiples
+	c.EscalationPopulationMultiple = next.EscalationPopulationMultiple
+	c.Routes = next.Routes
+
+	for _, change := range Diff(cfg, next) {
+		if isReloadable(change.Key) {
+			applied = append(applied, change)
+		} else {
+			ignored = append(ignored, change)
+		}
+	}
+	return &c, applied, ignored
+}
+
+// Diff lists the settings that differ between old and next, sorted by key.
+func Diff(old, next *Config) []Change {
+	before, after := flatten(old), flatten(next)
+	keys := maps.Clone(before)
+	maps.Copy(keys, after)
+
+	var changes []Change
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		if before[key] == after[key] {
+			continue
+		}
+		change := Change{Key: key, Old: before[key], New: after[key]}
+		if slices.Contains(secrets, key) {
+			change.Old, change.New = redactValue(change.Old), redactValue(change.New)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func isReloadable(key string) bool {
+	for _, prefix := range reloadable {
+		if key == prefix || (strings.HasSuffix(prefix, ".") && strings.HasPrefix(key, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactValue(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// flatten returns every setting in cfg keyed by its dotted config file key,
+// e.g. "grpc.tls.ca_file". Map entries get a key each.
+func flatten(cfg *Config) map[string]string {
+	flat := make(map[string]string)
+	flattenValue(flat, "", reflect.ValueOf(toFile(cfg)).Elem())
+	return flat
+}
+
+func flattenValue(flat map[string]string, key string, v reflect.Value) {
+	join := func(name string) string {
+		if key == "" {
+			return name
+		}
+		return key + "." + name
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := range v.NumField() {
+			name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+			flattenValue(flat, join(name), v.Field(i))
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			flattenValue(flat, join(k.String()), v.MapIndex(k))
+		}
+	default:
+		flat[key] = fmt.Sprint(v.Interface())
+	}
+}
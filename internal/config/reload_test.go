@@ -0,0 +1,104 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+func TestDiff(t *testing.T) {
+	old := defaults()
+	old.Token = "old-token"
+	next := defaults()
+	next.Token = "new-token"
+	next.MinMagnitude = 6
+	next.GRPCAddresses = []string{"primary:50051", "backup:50051"}
+	next.EscalationRoles = map[disastersv1.DisasterType]string{disastersv1.DisasterType_FLOOD: "111"}
+	next.BackfillWindow = 48 * time.Hour
+
+	want := []Change{
+		{Key: "backfill.window", Old: "24h0m0s", New: "48h0m0s"},
+		{Key: "discord.token", Old: "REDACTED", New: "REDACTED"},
+		{Key: "escalation.roles.FLOOD", Old: "", New: "111"},
+		{Key: "filter.min_magnitude", Old: "5", New: "6"},
+		{Key: "grpc.addresses", Old: "[localhost:50051]", New: "[primary:50051 backup:50051]"},
+	}
+	if got := Diff(old, next); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+
+	if got := Diff(old, old); len(got) != 0 {
+		t.Errorf("Diff() of the same config = %+v, want none", got)
+	}
+}
+
+func TestConfig_Reload(t *testing.T) {
+	current := defaults()
+	current.ChannelID = "111"
+
+	next := defaults()
+	next.ChannelID = "222"
+	next.MinMagnitude = 6.5
+	next.AlertLevel = disastersv1.AlertLevel_RED
+	next.MinPopulation = 1000000
+	next.Routes = []Route{{ChannelID: "789", Rule: mustCompile(t, "alert_level == RED")}}
+	next.Thresholds = map[disastersv1.DisasterType]Threshold{disastersv1.DisasterType_FLOOD: {MinPopulation: 2000000, Match: MatchAll}}
+	next.EscalationRoles = map[disastersv1.DisasterType]string{disastersv1.DisasterType_UNSPECIFIED: "333"}
+	next.EscalationPopulationMultiple = 3
+	next.EscalationPopulationMultiples = map[disastersv1.DisasterType]float64{disastersv1.DisasterType_EARTHQUAKE: 5}
+	next.StorePath = "/data/other.db"
+	next.ReconnectMaxRetries = 10
+
+	reloaded, applied, ignored := current.Reload(next)
+
+	if reloaded.ChannelID != "222" || reloaded.MinMagnitude != 6.5 || reloaded.AlertLevel != disastersv1.AlertLevel_RED {
+		t.Errorf("reloaded filter = %q, %v, %v, want 222, 6.5, RED", reloaded.ChannelID, reloaded.MinMagnitude, reloaded.AlertLevel)
+	}
+	if reloaded.EscalationRoles[disastersv1.DisasterType_UNSPECIFIED] != "333" || reloaded.EscalationPopulationMultiple != 3 {
+		t.Errorf("reloaded escalation = %v, %v, want *=333, 3", reloaded.EscalationRoles, reloaded.EscalationPopulationMultiple)
+	}
+	if got := reloaded.PopulationMultiple(disastersv1.DisasterType_EARTHQUAKE); got != 5 {
+		t.Errorf("reloaded PopulationMultiple(EARTHQUAKE) = %v, want 5", got)
+	}
+	if reloaded.StorePath != "state.db" || reloaded.ReconnectMaxRetries != 5 {
+		t.Errorf("reloaded StorePath, ReconnectMaxRetries = %q, %d, want them unchanged", reloaded.StorePath, reloaded.ReconnectMaxRetries)
+	}
+	if current.ChannelID != "111" {
+		t.Error("Reload() modified the current config")
+	}
+
+	keys := func(changes []Change) []string {
+		var keys []string
+		for _, c := range changes {
+			keys = append(keys, c.Key)
+		}
+		return keys
+	}
+	wantApplied := []string{
+		"discord.channel_id",
+		"escalation.population_multiple",
+		"escalation.population_multiples.EARTHQUAKE",
+		"escalation.roles.*",
+		"filter.alert_level",
+		"filter.min_magnitude",
+		"filter.min_population",
+		"filter.thresholds.FLOOD.match",
+		"filter.thresholds.FLOOD.min_magnitude",
+		"filter.thresholds.FLOOD.min_population",
+		"routes",
+	}
+	if got := keys(applied); !reflect.DeepEqual(got, wantApplied) {
+		t.Errorf("applied = %q, want %q", got, wantApplied)
+	}
+	wantIgnored := []string{"reconnect.max_retries", "store_path"}
+	if got := keys(ignored); !reflect.DeepEqual(got, wantIgnored) {
+		t.Errorf("ignored = %q, want %q", got, wantIgnored)
+	}
+
+	// Whatever Reload reports as applied must actually be copied over
+	if remaining := Diff(reloaded, next); !reflect.DeepEqual(keys(remaining), wantIgnored) {
+		t.Errorf("Diff(reloaded, next) = %q, want only the ignored settings", keys(remaining))
+	}
+}
@@ -0,0 +1,12 @@
+package config
+
+import (
+	"github.com/mr1hm/disaster-alerts-bot/internal/rules"
+)
+
+// Route posts every disaster matching Rule to ChannelID, in addition to the
+// default channel and subscriptions.
+type Route struct {
+	ChannelID string
+	Rule      *rules.Rule
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+const (
+	MatchAll = "all"
+	MatchAny = "any"
+)
+
+// Threshold decides which disasters of a type are posted to the default
+// channel. Each condition is skipped when left at zero. Match is MatchAll if
+// every remaining condition must hold, or MatchAny if one is enough. A
+// threshold without conditions passes everything.
+type Threshold struct {
+	AlertLevel    disastersv1.AlertLevel // Minimum alert level
+	MinMagnitude  float64
+	MinPopulation int64 // Minimum affected population
+	Match         string
+}
+
+// Threshold returns the default channel's filter for disasters of type t:
+// its entry in Thresholds, else the "*" entry, else the filter built from
+// MinMagnitude, AlertLevel and MinPopulation. That filter needs both the
+// magnitude and the population for earthquakes, and the alert level or the
+// population for other types; an UNKNOWN alert level lets every disaster of
+// other types through.
+func (cfg *Config) Threshold(t disastersv1.DisasterType) Threshold {
+	if th, ok := cfg.Thresholds[t]; ok {
+		return th
+	}
+	if th, ok := cfg.Thresholds[disastersv1.DisasterType_UNSPECIFIED]; ok {
+		return th
+	}
+	if t == disastersv1.DisasterType_EARTHQUAKE {
+		return Threshold{MinMagnitude: cfg.MinMagnitude, MinPopulation: cfg.MinPopulation, Match: MatchAll}
+	}
+	if cfg.AlertLevel == disastersv1.AlertLevel_UNKNOWN {
+		// Every alert level is at least UNKNOWN, so the population is moot
+		return Threshold{Match: MatchAny}
+	}
+	return Threshold{AlertLevel: cfg.AlertLevel, MinPopulation: cfg.MinPopulation, Match: MatchAny}
+}
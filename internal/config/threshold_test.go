@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+func TestConfig_Threshold(t *testing.T) {
+	legacy := &Config{MinMagnitude: 5.5, AlertLevel: disastersv1.AlertLevel_ORANGE, MinPopulation: 500000}
+
+	flood := Threshold{AlertLevel: disastersv1.AlertLevel_RED, Match: MatchAny}
+	fallback := Threshold{MinPopulation: 1000000, Match: MatchAll}
+	custom := &Config{
+		MinMagnitude:  5.5,
+		AlertLevel:    disastersv1.AlertLevel_ORANGE,
+		MinPopulation: 500000,
+		Thresholds: map[disastersv1.DisasterType]Threshold{
+			disastersv1.DisasterType_FLOOD:       flood,
+			disastersv1.DisasterType_UNSPECIFIED: fallback,
+		},
+	}
+
+	tests := []struct {
+		name string
+		cfg  *Config
+		t    disastersv1.DisasterType
+		want Threshold
+	}{
+		{"legacy earthquake", legacy, disastersv1.DisasterType_EARTHQUAKE, Threshold{MinMagnitude: 5.5, MinPopulation: 500000, Match: MatchAll}},
+		{"legacy flood", legacy, disastersv1.DisasterType_FLOOD, Threshold{AlertLevel: disastersv1.AlertLevel_ORANGE, MinPopulation: 500000, Match: MatchAny}},
+		{"legacy flood with any alert level", &Config{MinMagnitude: 5.5, MinPopulation: 500000}, disastersv1.DisasterType_FLOOD, Threshold{Match: MatchAny}},
+		{"legacy earthquake with any alert level", &Config{MinMagnitude: 5.5, MinPopulation: 500000}, disastersv1.DisasterType_EARTHQUAKE, Threshold{MinMagnitude: 5.5, MinPopulation: 500000, Match: MatchAll}},
+		{"own entry", custom, disastersv1.DisasterType_FLOOD, flood},
+		{"default entry", custom, disastersv1.DisasterType_CYCLONE, fallback},
+		{"default entry for earthquakes too", custom, disastersv1.DisasterType_EARTHQUAKE, fallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.Threshold(tt.t); got != tt.want {
+				t.Errorf("Threshold(%v) = %+v, want %+v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
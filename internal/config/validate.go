@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// FieldError is a setting that is missing or invalid. Field is the
+// environment variable, or the config file key for values that only failed
+// to parse from the file.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every problem found while loading the config.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		problems[i] = err.Error()
+	}
+	return "invalid config: " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Errors = append(e.Errors, &FieldError{Field: field, Err: fmt.Errorf(format, args...)})
+}
+
+// validate checks the merged config, whichever source each value came from.
+func (cfg *Config) validate(errs *ValidationError) {
+	if cfg.Token == "" {
+		errs.add("DISCORD_TOKEN", "required")
+	}
+	// The default channel is optional, since routes and subscriptions can
+	// carry every alert instead
+	if cfg.ChannelID != "" && !isSnowflake(cfg.ChannelID) {
+		errs.add("DISCORD_CHANNEL_ID", "%q is not a Discord ID", cfg.ChannelID)
+	}
+	if cfg.GuildID != "" && !isSnowflake(cfg.GuildID) {
+		errs.add("DISCORD_GUILD_ID", "%q is not a Discord ID", cfg.GuildID)
+	}
+	if cfg.MessageFormat != MessageFormatEmbed && cfg.MessageFormat != MessageFormatText {
+		errs.add("MESSAGE_FORMAT", "must be %q or %q, got %q", MessageFormatEmbed, MessageFormatText, cfg.MessageFormat)
+	}
+
+	if cfg.MinMagnitude < 0 || cfg.MinMagnitude > 10 {
+		errs.add("MIN_MAGNITUDE", "must be between 0 and 10, got %v", cfg.MinMagnitude)
+	}
+	if _, ok := disastersv1.AlertLevel_name[int32(cfg.AlertLevel)]; !ok {
+		errs.add("ALERT_LEVEL", "unknown alert level %d", cfg.AlertLevel)
+	}
+	if cfg.MinPopulation < 0 {
+		errs.add("MIN_POPULATION", "must not be negative, got %d", cfg.MinPopulation)
+	}
+	for _, t := range slices.Sorted(maps.Keys(cfg.Thresholds)) {
+		th, field := cfg.Thresholds[t], "filter.thresholds."+typeName(t)
+		if th.MinMagnitude < 0 || th.MinMagnitude > 10 {
+			errs.add(field+".min_magnitude", "must be between 0 and 10, got %v", th.MinMagnitude)
+		}
+		if th.MinPopulation < 0 {
+			errs.add(field+".min_population", "must not be negative, got %d", th.MinPopulation)
+		}
+		if th.Match != MatchAll && th.Match != MatchAny {
+			errs.add(field+".match", "must be %q or %q, got %q", MatchAll, MatchAny, th.Match)
+		}
+	}
+
+	for i, r := range cfg.Routes {
+		field := fmt.Sprintf("routes[%d].channel_id", i)
+		if r.ChannelID == "" {
+			errs.add(field, "required")
+		} else if !isSnowflake(r.ChannelID) {
+			errs.add(field, "%q is not a Discord ID", r.ChannelID)
+		}
+	}
+
+	for _, t := range slices.Sorted(maps.Keys(cfg.EscalationRoles)) {
+		if roleID := cfg.EscalationRoles[t]; !isSnowflake(roleID) {
+			errs.add("ESCALATION_ROLES", "role %q for %s is not a Discord ID", roleID, t)
+		}
+	}
+	if cfg.EscalationPopulationMultiple < 0 {
+		errs.add("ESCALATION_POPULATION_MULTIPLE", "must not be negative, got %v", cfg.EscalationPopulationMultiple)
+	}
+	for _, t := range slices.Sorted(maps.Keys(cfg.EscalationPopulationMultiples)) {
+		if mult := cfg.EscalationPopulationMultiples[t]; mult < 0 {
+			errs.add("ESCALATION_POPULATION_MULTIPLES", "multiple for %s must not be negative, got %v", t, mult)
+		}
+	}
+
+	cfg.validateUpstream(errs)
+
+	if cfg.ReconnectInitialInterval <= 0 {
+		errs.add("RECONNECT_INITIAL_INTERVAL", "must be positive, got %v", cfg.ReconnectInitialInterval)
+	}
+	if cfg.ReconnectMaxInterval < cfg.ReconnectInitialInterval {
+		errs.add("RECONNECT_MAX_INTERVAL", "must be at least RECONNECT_INITIAL_INTERVAL (%v), got %v", cfg.ReconnectInitialInterval, cfg.ReconnectMaxInterval)
+	}
+	if cfg.ReconnectMultiplier < 1 {
+		errs.add("RECONNECT_MULTIPLIER", "must be at least 1, got %v", cfg.ReconnectMultiplier)
+	}
+	if cfg.ReconnectJitter < 0 || cfg.ReconnectJitter > 1 {
+		errs.add("RECONNECT_JITTER", "must be between 0 and 1, got %v", cfg.ReconnectJitter)
+	}
+	if cfg.ReconnectMaxRetries < 0 {
+		errs.add("RECONNECT_MAX_RETRIES", "must not be negative, got %d", cfg.ReconnectMaxRetries)
+	}
+
+	if cfg.BackfillWindow <= 0 {
+		errs.add("BACKFILL_WINDOW", "must be positive, got %v", cfg.BackfillWindow)
+	}
+
+	if cfg.HTTPAddress != "" {
+		if _, _, err := net.SplitHostPort(cfg.HTTPAddress); err != nil {
+			errs.add("HTTP_ADDRESS", "%q is not host:port", cfg.HTTPAddress)
+		}
+	}
+	if cfg.HealthStreamTimeout < 0 {
+		errs.add("HEALTH_STREAM_TIMEOUT", "must not be negative, got %v", cfg.HealthStreamTimeout)
+	}
+	if cfg.TracingExporter != "" && cfg.TracingExporter != "otlp" && cfg.TracingExporter != "stdout" {
+		errs.add("TRACING_EXPORTER", "must be \"otlp\", \"stdout\" or empty, got %q", cfg.TracingExporter)
+	}
+}
+
+// validateUpstream checks the settings for connecting to the upstream server
+// and listing disasters from it.
+func (cfg *Config) validateUpstream(errs *ValidationError) {
+	if len(cfg.GRPCAddresses) == 0 {
+		errs.add("GRPC_ADDRESS", "required")
+	}
+	for _, addr := range cfg.GRPCAddresses {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			errs.add("GRPC_ADDRESS", "%q is not host:port", addr)
+		}
+	}
+	if (cfg.GRPCCertFile == "") != (cfg.GRPCKeyFile == "") {
+		errs.add("GRPC_TLS_CERT_FILE", "must be set together with GRPC_TLS_KEY_FILE")
+	}
+	if (cfg.GRPCAuthToken != "" || cfg.GRPCAuthTokenFile != "") && !cfg.GRPCTLS && !cfg.GRPCAuthAllowInsecure {
+		errs.add("GRPC_AUTH_TOKEN", "requires GRPC_TLS or GRPC_AUTH_ALLOW_INSECURE")
+	}
+	if cfg.GRPCHealthCheckInterval <= 0 {
+		errs.add("GRPC_HEALTH_CHECK_INTERVAL", "must be positive, got %v", cfg.GRPCHealthCheckInterval)
+	}
+	if cfg.GRPCKeepaliveTime < 0 {
+		errs.add("GRPC_KEEPALIVE_TIME", "must not be negative, got %v", cfg.GRPCKeepaliveTime)
+	}
+	if cfg.GRPCKeepaliveTimeout <= 0 {
+		errs.add("GRPC_KEEPALIVE_TIMEOUT", "must be positive, got %v", cfg.GRPCKeepaliveTimeout)
+	}
+	if cfg.StreamIdleTimeout < 0 {
+		errs.add("STREAM_IDLE_TIMEOUT", "must not be negative, got %v", cfg.StreamIdleTimeout)
+	}
+	if cfg.BackfillPageSize <= 0 {
+		errs.add("BACKFILL_PAGE_SIZE", "must be positive, got %d", cfg.BackfillPageSize)
+	}
+}
+
+// isSnowflake reports whether s looks like a Discord ID.
+func isSnowflake(s string) bool {
+	id, err := strconv.ParseUint(s, 10, 64)
+	return err == nil && id > 0
+}
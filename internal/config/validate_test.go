@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func fields(err error) []string {
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+	var fields []string
+	for _, fe := range invalid.Errors {
+		fields = append(fields, fe.Field)
+	}
+	return fields
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{"missing token", map[string]string{"DISCORD_TOKEN": ""}, []string{"DISCORD_TOKEN"}},
+		{"channel not a snowflake", map[string]string{"DISCORD_CHANNEL_ID": "#alerts"}, []string{"DISCORD_CHANNEL_ID"}},
+		{"guild not a snowflake", map[string]string{"DISCORD_GUILD_ID": "0"}, []string{"DISCORD_GUILD_ID"}},
+		{"magnitude not a number", map[string]string{"MIN_MAGNITUDE": "big"}, []string{"MIN_MAGNITUDE"}},
+		{"magnitude out of range", map[string]string{"MIN_MAGNITUDE": "11"}, []string{"MIN_MAGNITUDE"}},
+		{"negative magnitude", map[string]string{"MIN_MAGNITUDE": "-1"}, []string{"MIN_MAGNITUDE"}},
+		{"unknown alert level", map[string]string{"ALERT_LEVEL": "PURPLE"}, []string{"ALERT_LEVEL"}},
+		{"population not a number", map[string]string{"MIN_POPULATION": "lots"}, []string{"MIN_POPULATION"}},
+		{"negative population", map[string]string{"MIN_POPULATION": "-1"}, []string{"MIN_POPULATION"}},
+		{"lowercase alert level", map[string]string{"ALERT_LEVEL": "red"}, []string{"ALERT_LEVEL"}},
+		{"unknown message format", map[string]string{"MESSAGE_FORMAT": "html"}, []string{"MESSAGE_FORMAT"}},
+		{"invalid bool", map[string]string{"EPHEMERAL_REPLIES": "sometimes"}, []string{"EPHEMERAL_REPLIES"}},
+		{"invalid GRPC_TLS", map[string]string{"GRPC_TLS": "maybe"}, []string{"GRPC_TLS"}},
+		{"malformed escalation role", map[string]string{"ESCALATION_ROLES": "FLOOD="}, []string{"ESCALATION_ROLES"}},
+		{"unknown escalation type", map[string]string{"ESCALATION_ROLES": "BOGUS=333"}, []string{"ESCALATION_ROLES"}},
+		{"escalation role not a snowflake", map[string]string{"ESCALATION_ROLES": "FLOOD=@oncall"}, []string{"ESCALATION_ROLES"}},
+		{"negative population multiple", map[string]string{"ESCALATION_POPULATION_MULTIPLE": "-2"}, []string{"ESCALATION_POPULATION_MULTIPLE"}},
+		{"malformed population multiple", map[string]string{"ESCALATION_POPULATION_MULTIPLES": "FLOOD=lots"}, []string{"ESCALATION_POPULATION_MULTIPLES"}},
+		{"negative type population multiple", map[string]string{"ESCALATION_POPULATION_MULTIPLES": "FLOOD=-1"}, []string{"ESCALATION_POPULATION_MULTIPLES"}},
+		{"empty grpc address", map[string]string{"GRPC_ADDRESS": ","}, []string{"GRPC_ADDRESS"}},
+		{"grpc address without port", map[string]string{"GRPC_ADDRESS": "localhost"}, []string{"GRPC_ADDRESS"}},
+		{"cert without key", map[string]string{"GRPC_TLS_CERT_FILE": "c.pem"}, []string{"GRPC_TLS_CERT_FILE"}},
+		{"token without TLS", map[string]string{"GRPC_AUTH_TOKEN": "secret"}, []string{"GRPC_AUTH_TOKEN"}},
+		{"token file without TLS", map[string]string{"GRPC_AUTH_TOKEN_FILE": "/run/secrets/token"}, []string{"GRPC_AUTH_TOKEN"}},
+		{"invalid duration", map[string]string{"BACKFILL_WINDOW": "a day"}, []string{"BACKFILL_WINDOW"}},
+		{"zero backfill window", map[string]string{"BACKFILL_WINDOW": "0s"}, []string{"BACKFILL_WINDOW"}},
+		{"zero page size", map[string]string{"BACKFILL_PAGE_SIZE": "0"}, []string{"BACKFILL_PAGE_SIZE"}},
+		{"page size overflow", map[string]string{"BACKFILL_PAGE_SIZE": "99999999999"}, []string{"BACKFILL_PAGE_SIZE"}},
+		{"negative retries", map[string]string{"RECONNECT_MAX_RETRIES": "-1"}, []string{"RECONNECT_MAX_RETRIES"}},
+		{"max below initial interval", map[string]string{"RECONNECT_INITIAL_INTERVAL": "2m"}, []string{"RECONNECT_MAX_INTERVAL"}},
+		{"multiplier below one", map[string]string{"RECONNECT_MULTIPLIER": "0.5"}, []string{"RECONNECT_MULTIPLIER"}},
+		{"jitter above one", map[string]string{"RECONNECT_JITTER": "1.5"}, []string{"RECONNECT_JITTER"}},
+		{"zero health check interval", map[string]string{"GRPC_HEALTH_CHECK_INTERVAL": "0s"}, []string{"GRPC_HEALTH_CHECK_INTERVAL"}},
+		{"negative keepalive", map[string]string{"GRPC_KEEPALIVE_TIME": "-1s"}, []string{"GRPC_KEEPALIVE_TIME"}},
+		{"zero keepalive timeout", map[string]string{"GRPC_KEEPALIVE_TIMEOUT": "0s"}, []string{"GRPC_KEEPALIVE_TIMEOUT"}},
+		{"negative idle timeout", map[string]string{"STREAM_IDLE_TIMEOUT": "-1m"}, []string{"STREAM_IDLE_TIMEOUT"}},
+		{"http address without port", map[string]string{"HTTP_ADDRESS": "localhost"}, []string{"HTTP_ADDRESS"}},
+		{"negative health stream timeout", map[string]string{"HEALTH_STREAM_TIMEOUT": "-5m"}, []string{"HEALTH_STREAM_TIMEOUT"}},
+		{"unknown tracing exporter", map[string]string{"TRACING_EXPORTER": "jaeger"}, []string{"TRACING_EXPORTER"}},
+		{
+			"every problem reported",
+			map[string]string{"DISCORD_TOKEN": "", "MIN_MAGNITUDE": "big", "ALERT_LEVEL": "PURPLE", "RECONNECT_JITTER": "2"},
+			[]string{"DISCORD_TOKEN", "MIN_MAGNITUDE", "ALERT_LEVEL", "RECONNECT_JITTER"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+
+			cfg, err := Load("")
+			if err == nil {
+				t.Fatalf("Load() = %+v, want an error", cfg)
+			}
+			got := fields(err)
+			slices.Sort(got)
+			want := slices.Sorted(slices.Values(tt.want))
+			if !slices.Equal(got, want) {
+				t.Errorf("invalid fields = %q, want %q (error: %v)", got, want, err)
+			}
+		})
+	}
+}
+
+func TestLoad_Valid(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"defaults", nil},
+		{"no default channel", map[string]string{"DISCORD_CHANNEL_ID": ""}},
+		{"token with TLS", map[string]string{"GRPC_AUTH_TOKEN": "secret", "GRPC_TLS": "true"}},
+		{"token allowed insecure", map[string]string{"GRPC_AUTH_TOKEN": "secret", "GRPC_AUTH_ALLOW_INSECURE": "true"}},
+		{"mutual TLS", map[string]string{"GRPC_TLS_CERT_FILE": "c.pem", "GRPC_TLS_KEY_FILE": "k.pem"}},
+		{"disabled timeouts", map[string]string{"GRPC_KEEPALIVE_TIME": "0s", "STREAM_IDLE_TIMEOUT": "0s", "HEALTH_STREAM_TIMEOUT": "0s"}},
+		{"disabled HTTP server", map[string]string{"HTTP_ADDRESS": ""}},
+		{"zero magnitude", map[string]string{"MIN_MAGNITUDE": "0"}},
+		{"unlimited retries", map[string]string{"RECONNECT_MAX_RETRIES": "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+
+			if _, err := Load(""); err != nil {
+				t.Errorf("Load() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	clearEnv()
+	os.Unsetenv("DISCORD_CHANNEL_ID")
+
+	path := writeConfig(t, `
+discord:
+  channel_id: alerts
+filter:
+  alert_level: PURPLE
+  thresholds:
+    FLOOD:
+      alert_level: BRIGHT_RED
+      min_magnitude: 12
+      min_population: -5
+      match: either
+escalation:
+  roles:
+    METEOR: "1"
+routes:
+  - channel_id: ""
+    rule: alert_level >= ORANGE
+  - channel_id: "#floods"
+    rule: type == FLOOD
+  - channel_id: "42"
+    rule: magnitude > "5"
+`)
+
+	_, err := Load(path)
+	want := []string{
+		"DISCORD_CHANNEL_ID",
+		"escalation.roles",
+		"filter.alert_level",
+		"filter.thresholds.FLOOD.alert_level",
+		"filter.thresholds.FLOOD.match",
+		"filter.thresholds.FLOOD.min_magnitude",
+		"filter.thresholds.FLOOD.min_population",
+		"routes[0].channel_id",
+		"routes[1].channel_id",
+		"routes[2].rule",
+	}
+	if got := fields(err); !slices.Equal(slices.Sorted(slices.Values(got)), want) {
+		t.Errorf("invalid fields = %q, want %q (error: %v)", got, want, err)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{}
+	err.add("DISCORD_TOKEN", "required")
+	err.add("MIN_MAGNITUDE", "%q is not a number", "big")
+
+	want := `invalid config: DISCORD_TOKEN: required; MIN_MAGNITUDE: "big" is not a number`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	var fe *FieldError
+	if !errors.As(error(err), &fe) || fe.Field != "DISCORD_TOKEN" {
+		t.Errorf("errors.As(*FieldError) = %v, want the first field error", fe)
+	}
+	if !strings.Contains(err.Errors[1].Error(), "MIN_MAGNITUDE") {
+		t.Errorf("FieldError.Error() = %q, want the field name", err.Errors[1].Error())
+	}
+}
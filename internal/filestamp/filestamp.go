@@ -0,0 +1,24 @@
+// Package filestamp tells when a file has changed on disk without reading it.
+package filestamp
+
+import (
+	"os"
+	"time"
+)
+
+// Stamp identifies a version of a file by its size and modification time.
+// Stamps of a file compare equal until it changes. The zero value matches no
+// file.
+type Stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Stat returns the stamp of the file at path.
+func Stat(path string) (Stamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Stamp{}, err
+	}
+	return Stamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
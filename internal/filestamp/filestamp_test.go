@@ -0,0 +1,36 @@
+package filestamp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("one"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if again, _ := Stat(path); again != first {
+		t.Errorf("Stat() = %v, want unchanged %v", again, first)
+	}
+	if first == (Stamp{}) {
+		t.Error("Stat() = zero stamp for an existing file")
+	}
+
+	if err := os.WriteFile(path, []byte("three"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := Stat(path); changed == first {
+		t.Error("Stat() unchanged after the file was rewritten")
+	}
+
+	if _, err := Stat(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Stat() error = nil for a missing file")
+	}
+}
@@ -0,0 +1,154 @@
+package geo
+
+import (
+	"math"
+)
+
+const earthRadiusKm = 6371.0
+
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Area is a region of interest that disasters can be matched against.
+type Area interface {
+	Name() string
+	Contains(p Point) bool
+	// DistanceKm returns 0 if p is inside the area, otherwise the great-circle
+	// distance from p to the area's boundary.
+	DistanceKm(p Point) float64
+}
+
+// Distance returns the great-circle distance between a and b in kilometers
+// using the haversine formula.
+func Distance(a, b Point) float64 {
+	return angularDistance(a, b) * earthRadiusKm
+}
+
+// Nearest returns the area closest to p and its distance, or nil if areas is
+// empty.
+func Nearest(areas []Area, p Point) (Area, float64) {
+	var nearest Area
+	best := math.Inf(1)
+	for _, a := range areas {
+		if d := a.DistanceKm(p); d < best {
+			nearest, best = a, d
+		}
+	}
+	return nearest, best
+}
+
+// Circle is a point with a radius.
+type Circle struct {
+	Label    string
+	Center   Point
+	RadiusKm float64
+}
+
+func (c Circle) Name() string {
+	return c.Label
+}
+
+func (c Circle) Contains(p Point) bool {
+	return Distance(c.Center, p) <= c.RadiusKm
+}
+
+func (c Circle) DistanceKm(p Point) float64 {
+	return math.Max(0, Distance(c.Center, p)-c.RadiusKm)
+}
+
+// Polygon is one or more outer rings, as loaded from a GeoJSON Polygon or
+// MultiPolygon. Holes are not supported. Containment is tested in planar
+// lon/lat space, which is accurate for areas that don't span the antimeridian.
+type Polygon struct {
+	Label string
+	Rings [][]Point
+}
+
+func (pg *Polygon) Name() string {
+	return pg.Label
+}
+
+func (pg *Polygon) Contains(p Point) bool {
+	for _, ring := range pg.Rings {
+		if ringContains(ring, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (pg *Polygon) DistanceKm(p Point) float64 {
+	if pg.Contains(p) {
+		return 0
+	}
+
+	best := math.Inf(1)
+	for _, ring := range pg.Rings {
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			best = math.Min(best, segmentDistance(p, a, b))
+		}
+	}
+	return best
+}
+
+// ringContains uses ray casting: a point is inside if a ray from it crosses
+// the ring's edges an odd number of times.
+func ringContains(ring []Point, p Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lon < (b.Lon-a.Lon)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// segmentDistance returns the great-circle distance from p to the arc a-b,
+// using the cross-track distance when p projects onto the arc and the
+// nearest endpoint otherwise.
+func segmentDistance(p, a, b Point) float64 {
+	dAP := angularDistance(a, p)
+	dAB := angularDistance(a, b)
+	if dAB == 0 {
+		return dAP * earthRadiusKm
+	}
+
+	delta := bearing(a, p) - bearing(a, b)
+	crossTrack := math.Asin(math.Sin(dAP) * math.Sin(delta))
+
+	if math.Cos(delta) < 0 {
+		return dAP * earthRadiusKm
+	}
+	alongTrack := math.Acos(math.Min(1, math.Cos(dAP)/math.Cos(crossTrack)))
+	if alongTrack > dAB {
+		return Distance(b, p)
+	}
+	return math.Abs(crossTrack) * earthRadiusKm
+}
+
+func angularDistance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(b.Lon - a.Lon)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+func bearing(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLon := radians(b.Lon - a.Lon)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Atan2(y, x)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
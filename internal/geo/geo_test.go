@@ -0,0 +1,114 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func approx(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{"same point", Point{35.6762, 139.6503}, Point{35.6762, 139.6503}, 0},
+		{"tokyo to osaka", Point{35.6762, 139.6503}, Point{34.6937, 135.5023}, 392.4},
+		{"london to new york", Point{51.5074, -0.1278}, Point{40.7128, -74.0060}, 5570},
+		{"across antimeridian", Point{0, 179.5}, Point{0, -179.5}, 111.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); !approx(got, tt.want, tt.want*0.01+0.1) {
+				t.Errorf("Distance() = %.1f, want ~%.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircle(t *testing.T) {
+	c := Circle{Label: "tokyo", Center: Point{35.6762, 139.6503}, RadiusKm: 100}
+
+	if !c.Contains(Point{35.5, 139.7}) {
+		t.Error("Contains() = false for point inside radius")
+	}
+	if c.DistanceKm(Point{35.5, 139.7}) != 0 {
+		t.Error("DistanceKm() != 0 for point inside radius")
+	}
+
+	osaka := Point{34.6937, 135.5023}
+	if c.Contains(osaka) {
+		t.Error("Contains() = true for osaka")
+	}
+	if got := c.DistanceKm(osaka); !approx(got, 292.4, 1) {
+		t.Errorf("DistanceKm(osaka) = %.1f, want ~292.4", got)
+	}
+}
+
+// square is a 1°x1° box with corners at (0,0) and (1,1).
+var square = &Polygon{
+	Label: "square",
+	Rings: [][]Point{{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}},
+}
+
+func TestPolygon_Contains(t *testing.T) {
+	tests := []struct {
+		p    Point
+		want bool
+	}{
+		{Point{0.5, 0.5}, true},
+		{Point{0.1, 0.9}, true},
+		{Point{1.5, 0.5}, false},
+		{Point{-0.5, 0.5}, false},
+		{Point{0.5, 2}, false},
+	}
+
+	for _, tt := range tests {
+		if got := square.Contains(tt.p); got != tt.want {
+			t.Errorf("Contains(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPolygon_DistanceKm(t *testing.T) {
+	// One degree of latitude is ~111.2 km
+	tests := []struct {
+		name string
+		p    Point
+		want float64
+	}{
+		{"inside", Point{0.5, 0.5}, 0},
+		{"north of top edge", Point{2, 0.5}, 111.2},
+		{"west of left edge", Point{0.5, -1}, 111.2},
+		{"beyond corner", Point{2, 2}, 157.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := square.DistanceKm(tt.p); !approx(got, tt.want, 1) {
+				t.Errorf("DistanceKm(%v) = %.1f, want ~%.1f", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearest(t *testing.T) {
+	far := Circle{Label: "far", Center: Point{10, 10}, RadiusKm: 1}
+	near := Circle{Label: "near", Center: Point{0, 1}, RadiusKm: 1}
+
+	area, dist := Nearest([]Area{far, near}, Point{0, 0})
+	if area == nil || area.Name() != "near" {
+		t.Fatalf("Nearest() = %v, want near", area)
+	}
+	if !approx(dist, 110.2, 1) {
+		t.Errorf("Nearest() distance = %.1f, want ~110.2", dist)
+	}
+
+	if area, _ := Nearest(nil, Point{}); area != nil {
+		t.Errorf("Nearest(nil) = %v, want nil", area)
+	}
+}
@@ -0,0 +1,105 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string         `json:"type"`
+	Properties map[string]any `json:"properties"`
+	Geometry   struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// LoadGeoJSON reads named polygons from a GeoJSON FeatureCollection (or a
+// single Feature). Each feature must have a Polygon or MultiPolygon geometry
+// and a unique "name" property.
+func LoadGeoJSON(path string) (map[string]*Polygon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading geojson: %w", err)
+	}
+	return ParseGeoJSON(data)
+}
+
+func ParseGeoJSON(data []byte) (map[string]*Polygon, error) {
+	var fc featureCollection
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, fmt.Errorf("parsing geojson: %w", err)
+	}
+
+	features := fc.Features
+	if fc.Type == "Feature" {
+		var f feature
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("parsing geojson: %w", err)
+		}
+		features = []feature{f}
+	} else if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("unsupported geojson type %q", fc.Type)
+	}
+
+	polygons := make(map[string]*Polygon, len(features))
+	for i, f := range features {
+		name, _ := f.Properties["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("feature %d: missing name property", i)
+		}
+		if _, ok := polygons[name]; ok {
+			return nil, fmt.Errorf("feature %d: duplicate name %q", i, name)
+		}
+
+		rings, err := parseRings(f.Geometry.Type, f.Geometry.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("feature %q: %w", name, err)
+		}
+		polygons[name] = &Polygon{Label: name, Rings: rings}
+	}
+	return polygons, nil
+}
+
+// parseRings returns the outer ring of each polygon. GeoJSON positions are
+// [longitude, latitude].
+func parseRings(geomType string, coords json.RawMessage) ([][]Point, error) {
+	var polygons [][][][]float64
+	switch geomType {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(coords, &polygon); err != nil {
+			return nil, fmt.Errorf("parsing coordinates: %w", err)
+		}
+		polygons = [][][][]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(coords, &polygons); err != nil {
+			return nil, fmt.Errorf("parsing coordinates: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q", geomType)
+	}
+
+	var rings [][]Point
+	for _, polygon := range polygons {
+		if len(polygon) == 0 || len(polygon[0]) < 4 {
+			return nil, fmt.Errorf("polygon needs an outer ring of at least 4 positions")
+		}
+
+		ring := make([]Point, 0, len(polygon[0]))
+		for _, pos := range polygon[0] {
+			if len(pos) < 2 {
+				return nil, fmt.Errorf("invalid position %v", pos)
+			}
+			ring = append(ring, Point{Lat: pos[1], Lon: pos[0]})
+		}
+		rings = append(rings, ring)
+	}
+	return rings, nil
+}
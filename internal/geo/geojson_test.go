@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"testing"
+)
+
+func TestParseGeoJSON(t *testing.T) {
+	data := []byte(`{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {"name": "box"},
+				"geometry": {"type": "Polygon", "coordinates": [[[0,0],[1,0],[1,1],[0,1],[0,0]]]}
+			},
+			{
+				"type": "Feature",
+				"properties": {"name": "islands"},
+				"geometry": {"type": "MultiPolygon", "coordinates": [
+					[[[10,10],[11,10],[11,11],[10,11],[10,10]]],
+					[[[20,20],[21,20],[21,21],[20,21],[20,20]]]
+				]}
+			}
+		]
+	}`)
+
+	polygons, err := ParseGeoJSON(data)
+	if err != nil {
+		t.Fatalf("ParseGeoJSON() error = %v", err)
+	}
+	if len(polygons) != 2 {
+		t.Fatalf("ParseGeoJSON() len = %d, want 2", len(polygons))
+	}
+
+	// GeoJSON is [lon, lat]
+	if !polygons["box"].Contains(Point{Lat: 0.5, Lon: 0.5}) {
+		t.Error("box should contain (0.5, 0.5)")
+	}
+	if !polygons["islands"].Contains(Point{Lat: 20.5, Lon: 20.5}) {
+		t.Error("islands should contain (20.5, 20.5)")
+	}
+	if polygons["islands"].Contains(Point{Lat: 15, Lon: 15}) {
+		t.Error("islands should not contain (15, 15)")
+	}
+}
+
+func TestParseGeoJSON_SingleFeature(t *testing.T) {
+	data := []byte(`{
+		"type": "Feature",
+		"properties": {"name": "box"},
+		"geometry": {"type": "Polygon", "coordinates": [[[0,0],[1,0],[1,1],[0,1],[0,0]]]}
+	}`)
+
+	polygons, err := ParseGeoJSON(data)
+	if err != nil {
+		t.Fatalf("ParseGeoJSON() error = %v", err)
+	}
+	if polygons["box"] == nil {
+		t.Error("ParseGeoJSON() missing box")
+	}
+}
+
+func TestParseGeoJSON_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `nope`},
+		{"wrong type", `{"type": "Point"}`},
+		{"missing name", `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0,0],[1,0],[1,1],[0,0]]]}}]}`},
+		{"point geometry", `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {"name": "p"}, "geometry": {"type": "Point", "coordinates": [0,0]}}]}`},
+		{"short ring", `{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {"name": "p"}, "geometry": {"type": "Polygon", "coordinates": [[[0,0],[1,0],[0,0]]]}}]}`},
+		{"duplicate name", `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "properties": {"name": "p"}, "geometry": {"type": "Polygon", "coordinates": [[[0,0],[1,0],[1,1],[0,0]]]}},
+			{"type": "Feature", "properties": {"name": "p"}, "geometry": {"type": "Polygon", "coordinates": [[[0,0],[1,0],[1,1],[0,0]]]}}
+		]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseGeoJSON([]byte(tt.data)); err == nil {
+				t.Error("ParseGeoJSON() error = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+const probeTimeout = 5 * time.Second
+
+// upstream is one disaster API server.
+type upstream struct {
+	address string
+	conn    *grpc.ClientConn
+	client  disastersv1.DisasterServiceClient
+	healthy atomic.Bool
+}
+
+// Pool is a DisasterServiceClient spread over several upstream servers.
+// Streams go to one active upstream at a time and fail over to the next
+// healthy one when reopened; unary calls are spread round-robin over healthy
+// upstreams and retried on another when one is unavailable. Acknowledgements
+// are sent to every healthy upstream, since each may track sent disasters
+// separately.
+type Pool struct {
+	upstreams []*upstream
+	active    atomic.Int64
+	next      atomic.Uint64
+}
+
+var _ disastersv1.DisasterServiceClient = (*Pool)(nil)
+
+// NewPool creates a client for each address with the given dial options.
+func NewPool(addresses []string, opts ...grpc.DialOption) (*Pool, error) {
+	if len(addresses) == 0 {
+		return nil, errors.New("no upstream addresses")
+	}
+
+	p := &Pool{}
+	for _, addr := range addresses {
+		conn, err := grpc.NewClient(addr, opts...)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+		}
+		u := &upstream{address: addr, conn: conn, client: disastersv1.NewDisasterServiceClient(conn)}
+		u.healthy.Store(true)
+		p.upstreams = append(p.upstreams, u)
+	}
+	return p, nil
+}
+
+// Addresses returns the upstream addresses in configured order.
+func (p *Pool) Addresses() []string {
+	addrs := make([]string, len(p.upstreams))
+	for i, u := range p.upstreams {
+		addrs[i] = u.address
+	}
+	return addrs
+}
+
+// Active returns the address of the upstream streams are opened against.
+func (p *Pool) Active() string {
+	return p.upstreams[p.active.Load()].address
+}
+
+// Close closes every upstream connection.
+func (p *Pool) Close() error {
+	var errs []error
+	for _, u := range p.upstreams {
+		errs = append(errs, u.conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// RunHealthChecks probes every upstream each interval until ctx is done, so
+// unary calls skip servers that are down. It does nothing with a single
+// upstream.
+func (p *Pool) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	if len(p.upstreams) < 2 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, u := range p.upstreams {
+			p.probe(ctx, u)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe checks that u answers a minimal ListDisasters call and records the
+// result.
+func (p *Pool) probe(ctx context.Context, u *upstream) bool {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	_, err := u.client.ListDisasters(ctx, &disastersv1.ListDisastersRequest{Limit: 1})
+	healthy := err == nil || !unavailable(err)
+	p.setHealthy(u, healthy, err)
+	return healthy
+}
+
+func (p *Pool) setHealthy(u *upstream, healthy bool, err error) {
+	if was := u.healthy.Swap(healthy); was != healthy {
+		if healthy {
+			slog.Info("Upstream healthy", "address", u.address)
+		} else {
+			slog.Warn("Upstream unhealthy", "address", u.address, "error", err)
+		}
+	}
+}
+
+// streamUpstream returns the upstream to open a stream against: the active
+// one if it still answers, otherwise the next one that does. With none
+// answering it stays on the active upstream.
+func (p *Pool) streamUpstream(ctx context.Context) *upstream {
+	n := int64(len(p.upstreams))
+	active := p.active.Load()
+	if n == 1 {
+		return p.upstreams[active]
+	}
+
+	for i := range n {
+		idx := (active + i) % n
+		u := p.upstreams[idx]
+		if !p.probe(ctx, u) {
+			continue
+		}
+		if idx != active {
+			p.active.Store(idx)
+			slog.Warn("Failing over stream to upstream", "from", p.upstreams[active].address, "to", u.address)
+		}
+		return u
+	}
+	return p.upstreams[active]
+}
+
+// unaryOrder returns every upstream starting at the next round-robin
+// position, healthy ones first.
+func (p *Pool) unaryOrder() []*upstream {
+	n := uint64(len(p.upstreams))
+	start := p.next.Add(1) - 1
+
+	order := make([]*upstream, 0, n)
+	var unhealthy []*upstream
+	for i := range n {
+		u := p.upstreams[(start+i)%n]
+		if u.healthy.Load() {
+			order = append(order, u)
+		} else {
+			unhealthy = append(unhealthy, u)
+		}
+	}
+	return append(order, unhealthy...)
+}
+
+// unary runs call against upstreams in round-robin order, moving on to the
+// next only when one is unavailable.
+func unary[T any](ctx context.Context, p *Pool, call func(disastersv1.DisasterServiceClient) (T, error)) (T, error) {
+	var (
+		resp T
+		err  error
+	)
+	for _, u := range p.unaryOrder() {
+		resp, err = call(u.client)
+		if err == nil || !unavailable(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		p.setHealthy(u, false, err)
+	}
+	return resp, err
+}
+
+func (p *Pool) GetDisaster(ctx context.Context, in *disastersv1.GetDisasterRequest, opts ...grpc.CallOption) (*disastersv1.Disaster, error) {
+	return unary(ctx, p, func(c disastersv1.DisasterServiceClient) (*disastersv1.Disaster, error) {
+		return c.GetDisaster(ctx, in, opts...)
+	})
+}
+
+func (p *Pool) ListDisasters(ctx context.Context, in *disastersv1.ListDisastersRequest, opts ...grpc.CallOption) (*disastersv1.ListDisastersResponse, error) {
+	return unary(ctx, p, func(c disastersv1.DisasterServiceClient) (*disastersv1.ListDisastersResponse, error) {
+		return c.ListDisasters(ctx, in, opts...)
+	})
+}
+
+func (p *Pool) StreamDisasters(ctx context.Context, in *disastersv1.StreamDisastersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[disastersv1.Disaster], error) {
+	u := p.streamUpstream(ctx)
+	stream, err := u.client.StreamDisasters(ctx, in, opts...)
+	if err != nil && unavailable(err) {
+		p.setHealthy(u, false, err)
+	}
+	return stream, err
+}
+
+// AcknowledgeDisasters acknowledges on every healthy upstream, succeeding if
+// at least one accepts. Upstreams that miss an ack may resend the disaster,
+// which the caller deduplicates by ID.
+func (p *Pool) AcknowledgeDisasters(ctx context.Context, in *disastersv1.AcknowledgeDisastersRequest, opts ...grpc.CallOption) (*disastersv1.AcknowledgeDisastersResponse, error) {
+	if len(p.upstreams) == 1 {
+		return p.upstreams[0].client.AcknowledgeDisasters(ctx, in, opts...)
+	}
+
+	var (
+		resp *disastersv1.AcknowledgeDisastersResponse
+		errs []error
+	)
+	for _, u := range p.unaryOrder() {
+		if !u.healthy.Load() && resp != nil {
+			continue
+		}
+		r, err := u.client.AcknowledgeDisasters(ctx, in, opts...)
+		if err != nil {
+			if unavailable(err) {
+				p.setHealthy(u, false, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", u.address, err))
+			continue
+		}
+		if resp == nil {
+			resp = r
+		}
+	}
+	if resp == nil {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		slog.Warn("Failed to acknowledge on upstream", "error", err)
+	}
+	return resp, nil
+}
+
+// unavailable reports whether err means the server couldn't be reached, as
+// opposed to rejecting the request.
+func unavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
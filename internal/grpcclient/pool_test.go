@@ -0,0 +1,241 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// fakeUpstream is an in-process disaster API that tags everything it returns
+// with its name.
+type fakeUpstream struct {
+	disastersv1.UnimplementedDisasterServiceServer
+
+	name string
+	addr string
+	srv  *grpc.Server
+
+	mu    sync.Mutex
+	lists int
+	acked []string
+}
+
+func startUpstream(t *testing.T, name string) *fakeUpstream {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &fakeUpstream{name: name, addr: lis.Addr().String(), srv: grpc.NewServer()}
+	disastersv1.RegisterDisasterServiceServer(u.srv, u)
+	go u.srv.Serve(lis)
+	t.Cleanup(u.srv.Stop)
+	return u
+}
+
+func (u *fakeUpstream) GetDisaster(_ context.Context, req *disastersv1.GetDisasterRequest) (*disastersv1.Disaster, error) {
+	return &disastersv1.Disaster{Id: req.Id, Source: u.name}, nil
+}
+
+func (u *fakeUpstream) ListDisasters(context.Context, *disastersv1.ListDisastersRequest) (*disastersv1.ListDisastersResponse, error) {
+	u.mu.Lock()
+	u.lists++
+	u.mu.Unlock()
+	return &disastersv1.ListDisastersResponse{Disasters: []*disastersv1.Disaster{{Id: "eq-1", Source: u.name}}}, nil
+}
+
+func (u *fakeUpstream) StreamDisasters(_ *disastersv1.StreamDisastersRequest, stream grpc.ServerStreamingServer[disastersv1.Disaster]) error {
+	if err := stream.Send(&disastersv1.Disaster{Id: "eq-1", Source: u.name}); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func (u *fakeUpstream) AcknowledgeDisasters(_ context.Context, req *disastersv1.AcknowledgeDisastersRequest) (*disastersv1.AcknowledgeDisastersResponse, error) {
+	u.mu.Lock()
+	u.acked = append(u.acked, req.Ids...)
+	u.mu.Unlock()
+	return &disastersv1.AcknowledgeDisastersResponse{}, nil
+}
+
+func (u *fakeUpstream) listCalls() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.lists
+}
+
+func (u *fakeUpstream) ackedIDs() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return slices.Clone(u.acked)
+}
+
+func newTestPool(t *testing.T, upstreams ...*fakeUpstream) *Pool {
+	t.Helper()
+
+	addrs := make([]string, len(upstreams))
+	for i, u := range upstreams {
+		addrs[i] = u.addr
+	}
+	p, err := NewPool(addrs, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestNewPool_NoAddresses(t *testing.T) {
+	if _, err := NewPool(nil); err == nil {
+		t.Error("NewPool(nil) error = nil, want error")
+	}
+}
+
+func TestPool_ListDisasters_RoundRobin(t *testing.T) {
+	a, b := startUpstream(t, "a"), startUpstream(t, "b")
+	p := newTestPool(t, a, b)
+	ctx := testContext(t)
+
+	for range 4 {
+		if _, err := p.ListDisasters(ctx, &disastersv1.ListDisastersRequest{}); err != nil {
+			t.Fatalf("ListDisasters() error = %v", err)
+		}
+	}
+
+	if a.listCalls() != 2 || b.listCalls() != 2 {
+		t.Errorf("list calls = %d, %d, want 2 each", a.listCalls(), b.listCalls())
+	}
+}
+
+func TestPool_Unary_SkipsUnavailableUpstream(t *testing.T) {
+	a, b := startUpstream(t, "a"), startUpstream(t, "b")
+	p := newTestPool(t, a, b)
+	ctx := testContext(t)
+
+	a.srv.Stop()
+
+	for range 3 {
+		d, err := p.GetDisaster(ctx, &disastersv1.GetDisasterRequest{Id: "eq-1"})
+		if err != nil {
+			t.Fatalf("GetDisaster() error = %v, want served by the healthy upstream", err)
+		}
+		if d.Source != "b" {
+			t.Errorf("GetDisaster() served by %q, want b", d.Source)
+		}
+	}
+
+	b.srv.Stop()
+	if _, err := p.GetDisaster(ctx, &disastersv1.GetDisasterRequest{Id: "eq-1"}); err == nil {
+		t.Error("GetDisaster() error = nil with every upstream down, want error")
+	}
+}
+
+func TestPool_StreamDisasters_FailsOver(t *testing.T) {
+	a, b := startUpstream(t, "a"), startUpstream(t, "b")
+	p := newTestPool(t, a, b)
+
+	recvFrom := func() string {
+		t.Helper()
+		ctx, cancel := context.WithCancel(testContext(t))
+		defer cancel()
+
+		stream, err := p.StreamDisasters(ctx, &disastersv1.StreamDisastersRequest{})
+		if err != nil {
+			t.Fatalf("StreamDisasters() error = %v", err)
+		}
+		d, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		return d.Source
+	}
+
+	if got := recvFrom(); got != "a" {
+		t.Fatalf("stream from %q, want the first upstream", got)
+	}
+	// Reopening stays on the active upstream while it is healthy
+	if got := recvFrom(); got != "a" {
+		t.Fatalf("stream from %q, want to stay on a", got)
+	}
+
+	a.srv.Stop()
+	if got := recvFrom(); got != "b" {
+		t.Errorf("stream from %q after a went down, want b", got)
+	}
+	if p.Active() != b.addr {
+		t.Errorf("Active() = %q, want %q", p.Active(), b.addr)
+	}
+}
+
+func TestPool_AcknowledgeDisasters(t *testing.T) {
+	a, b := startUpstream(t, "a"), startUpstream(t, "b")
+	p := newTestPool(t, a, b)
+	ctx := testContext(t)
+
+	req := &disastersv1.AcknowledgeDisastersRequest{Ids: []string{"eq-1"}}
+	if _, err := p.AcknowledgeDisasters(ctx, req); err != nil {
+		t.Fatalf("AcknowledgeDisasters() error = %v", err)
+	}
+	if !slices.Equal(a.ackedIDs(), []string{"eq-1"}) || !slices.Equal(b.ackedIDs(), []string{"eq-1"}) {
+		t.Errorf("acked = %v, %v, want eq-1 on both upstreams", a.ackedIDs(), b.ackedIDs())
+	}
+
+	// One upstream down is enough to still succeed
+	a.srv.Stop()
+	req = &disastersv1.AcknowledgeDisastersRequest{Ids: []string{"fl-1"}}
+	if _, err := p.AcknowledgeDisasters(ctx, req); err != nil {
+		t.Fatalf("AcknowledgeDisasters() with one upstream down error = %v", err)
+	}
+	if !slices.Contains(b.ackedIDs(), "fl-1") {
+		t.Errorf("acked on b = %v, want fl-1", b.ackedIDs())
+	}
+
+	b.srv.Stop()
+	if _, err := p.AcknowledgeDisasters(ctx, req); err == nil {
+		t.Error("AcknowledgeDisasters() error = nil with every upstream down, want error")
+	}
+}
+
+func TestPool_RunHealthChecks(t *testing.T) {
+	a, b := startUpstream(t, "a"), startUpstream(t, "b")
+	p := newTestPool(t, a, b)
+
+	a.srv.Stop()
+
+	ctx, cancel := context.WithCancel(testContext(t))
+	done := make(chan struct{})
+	go func() {
+		p.RunHealthChecks(ctx, time.Hour)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.upstreams[0].healthy.Load() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if p.upstreams[0].healthy.Load() {
+		t.Error("stopped upstream still healthy after a health check")
+	}
+	if !p.upstreams[1].healthy.Load() {
+		t.Error("running upstream marked unhealthy")
+	}
+}
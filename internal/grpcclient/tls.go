@@ -0,0 +1,155 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/filestamp"
+)
+
+// TLSOptions configures TLS for the upstream connection. Without a CAFile the
+// system roots are used. CertFile and KeyFile together enable mutual TLS.
+// ServerName overrides the name verified against the server certificate.
+type TLSOptions struct {
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// NewTLSCredentials returns transport credentials for opts. The files are
+// checked on every handshake and re-read when they change, so rotated
+// certificates are picked up on the next reconnect without a restart.
+func NewTLSCredentials(opts TLSOptions) (credentials.TransportCredentials, error) {
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+
+	files := &tlsFiles{opts: opts}
+	if _, err := files.config(); err != nil {
+		return nil, err
+	}
+
+	return &tlsCredentials{
+		TransportCredentials: credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12, ServerName: opts.ServerName}),
+		files:                files,
+	}, nil
+}
+
+// tlsCredentials performs each client handshake with a TLS config built from
+// the current certificate files. Everything else is delegated to the embedded
+// credentials.
+type tlsCredentials struct {
+	credentials.TransportCredentials
+	files *tlsFiles
+}
+
+func (c *tlsCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	cfg, err := c.files.config()
+	if err != nil {
+		return nil, nil, err
+	}
+	return credentials.NewTLS(cfg).ClientHandshake(ctx, authority, conn)
+}
+
+func (c *tlsCredentials) Clone() credentials.TransportCredentials {
+	return &tlsCredentials{TransportCredentials: c.TransportCredentials.Clone(), files: c.files}
+}
+
+// tlsFiles caches the parsed CA pool and client certificate, reloading each
+// when its files change on disk.
+type tlsFiles struct {
+	opts TLSOptions
+
+	mu        sync.Mutex
+	pool      *x509.CertPool
+	poolStamp filestamp.Stamp
+	cert      *tls.Certificate
+	certStamp [2]filestamp.Stamp
+}
+
+func (f *tlsFiles) roots() (*x509.CertPool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stamp, err := filestamp.Stat(f.opts.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+	if f.pool != nil && stamp == f.poolStamp {
+		return f.pool, nil
+	}
+
+	pem, err := os.ReadFile(f.opts.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", f.opts.CAFile)
+	}
+
+	f.pool, f.poolStamp = pool, stamp
+	return pool, nil
+}
+
+func (f *tlsFiles) certificate() (*tls.Certificate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	certStamp, err := filestamp.Stat(f.opts.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading client certificate: %w", err)
+	}
+	keyStamp, err := filestamp.Stat(f.opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading client key: %w", err)
+	}
+	stamp := [2]filestamp.Stamp{certStamp, keyStamp}
+	if f.cert != nil && stamp == f.certStamp {
+		return f.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.opts.CertFile, f.opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading client certificate: %w", err)
+	}
+
+	f.cert, f.certStamp = &cert, stamp
+	return f.cert, nil
+}
+
+// config returns a TLS config using the current CA pool and client
+// certificate.
+func (f *tlsFiles) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: f.opts.ServerName,
+	}
+
+	if f.opts.CAFile != "" {
+		pool, err := f.roots()
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if f.opts.CertFile != "" {
+		cert, err := f.certificate()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{*cert}
+	}
+
+	return cfg, nil
+}
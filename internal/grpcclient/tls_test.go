@@ -0,0 +1,263 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// testCA is a throwaway certificate authority for issuing test certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key signed by ca. Server certificates
+// are valid for the given DNS names and 127.0.0.1 if names is empty.
+func (ca *testCA) issue(t *testing.T, server bool, names ...string) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.DNSNames = names
+		if len(names) == 0 {
+			tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes data to name in dir, bumping the modification time so a
+// rewrite within the same clock tick is still noticed.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now()
+	if info, err := os.Stat(path); err == nil && !info.ModTime().Before(mtime) {
+		mtime = info.ModTime().Add(time.Second)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+type disasterServer struct {
+	disastersv1.UnimplementedDisasterServiceServer
+}
+
+func (disasterServer) GetDisaster(_ context.Context, req *disastersv1.GetDisasterRequest) (*disastersv1.Disaster, error) {
+	return &disastersv1.Disaster{Id: req.Id}, nil
+}
+
+// startServer runs an in-process TLS gRPC server and returns its address.
+// A non-nil clientCA makes it require client certificates issued by it.
+func startServer(t *testing.T, ca *testCA, clientCA *testCA, names ...string) string {
+	t.Helper()
+
+	certPEM, keyPEM := ca.issue(t, true, names...)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if clientCA != nil {
+		cfg.ClientCAs = x509.NewCertPool()
+		cfg.ClientCAs.AddCert(clientCA.cert)
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(cfg)))
+	disastersv1.RegisterDisasterServiceServer(srv, disasterServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+// call makes one RPC to addr over a fresh connection using creds.
+func call(t *testing.T, addr string, creds credentials.TransportCredentials) error {
+	t.Helper()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = disastersv1.NewDisasterServiceClient(conn).GetDisaster(ctx, &disastersv1.GetDisasterRequest{Id: "eq-1"})
+	return err
+}
+
+func TestNewTLSCredentials(t *testing.T) {
+	ca := newTestCA(t, "server-ca")
+	clientCA := newTestCA(t, "client-ca")
+	otherCA := newTestCA(t, "other-ca")
+
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.pem", ca.pem)
+	otherCAFile := writeFile(t, dir, "other-ca.pem", otherCA.pem)
+	certPEM, keyPEM := clientCA.issue(t, false)
+	certFile := writeFile(t, dir, "client.pem", certPEM)
+	keyFile := writeFile(t, dir, "client-key.pem", keyPEM)
+
+	tlsAddr := startServer(t, ca, nil)
+	mtlsAddr := startServer(t, ca, clientCA)
+	namedAddr := startServer(t, ca, nil, "alerts.internal")
+
+	tests := []struct {
+		name    string
+		addr    string
+		opts    TLSOptions
+		wantErr bool
+	}{
+		{"tls", tlsAddr, TLSOptions{CAFile: caFile}, false},
+		{"untrusted server", tlsAddr, TLSOptions{CAFile: otherCAFile}, true},
+		{"mtls", mtlsAddr, TLSOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}, false},
+		{"mtls without client certificate", mtlsAddr, TLSOptions{CAFile: caFile}, true},
+		{"server name override", namedAddr, TLSOptions{CAFile: caFile, ServerName: "alerts.internal"}, false},
+		{"server name mismatch", namedAddr, TLSOptions{CAFile: caFile}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := NewTLSCredentials(tt.opts)
+			if err != nil {
+				t.Fatalf("NewTLSCredentials() error = %v", err)
+			}
+			if err := call(t, tt.addr, creds); (err != nil) != tt.wantErr {
+				t.Errorf("call error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTLSCredentials_InvalidOptions(t *testing.T) {
+	dir := t.TempDir()
+	garbage := writeFile(t, dir, "garbage.pem", []byte("not a certificate"))
+
+	tests := []struct {
+		name string
+		opts TLSOptions
+	}{
+		{"certificate without key", TLSOptions{CertFile: garbage}},
+		{"key without certificate", TLSOptions{KeyFile: garbage}},
+		{"missing CA file", TLSOptions{CAFile: filepath.Join(dir, "missing.pem")}},
+		{"CA file without certificates", TLSOptions{CAFile: garbage}},
+		{"invalid client certificate", TLSOptions{CertFile: garbage, KeyFile: garbage}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTLSCredentials(tt.opts); err == nil {
+				t.Error("NewTLSCredentials() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNewTLSCredentials_ReloadsRotatedFiles(t *testing.T) {
+	oldCA := newTestCA(t, "old-ca")
+	newCA := newTestCA(t, "new-ca")
+
+	dir := t.TempDir()
+	caFile := writeFile(t, dir, "ca.pem", oldCA.pem)
+	certPEM, keyPEM := oldCA.issue(t, false)
+	certFile := writeFile(t, dir, "client.pem", certPEM)
+	keyFile := writeFile(t, dir, "client-key.pem", keyPEM)
+
+	// The server has moved to the new CA for both sides
+	addr := startServer(t, newCA, newCA)
+
+	creds, err := NewTLSCredentials(TLSOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewTLSCredentials() error = %v", err)
+	}
+	if err := call(t, addr, creds); err == nil {
+		t.Fatal("call with old certificates succeeded, want error")
+	}
+
+	writeFile(t, dir, "ca.pem", newCA.pem)
+	certPEM, keyPEM = newCA.issue(t, false)
+	writeFile(t, dir, "client.pem", certPEM)
+	writeFile(t, dir, "client-key.pem", keyPEM)
+
+	if err := call(t, addr, creds); err != nil {
+		t.Errorf("call after rotation error = %v, want rotated certificates used", err)
+	}
+}
@@ -0,0 +1,87 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/filestamp"
+)
+
+// TokenOptions configures bearer token authentication. File takes precedence
+// over Token and is re-read when it changes. AllowInsecure permits sending the
+// token over a plaintext connection.
+type TokenOptions struct {
+	Token         string
+	File          string
+	AllowInsecure bool
+}
+
+// NewTokenCredentials returns per-RPC credentials that send an
+// "authorization: Bearer <token>" header with every call.
+func NewTokenCredentials(opts TokenOptions) (credentials.PerRPCCredentials, error) {
+	c := &tokenCredentials{opts: opts}
+	if _, err := c.token(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type tokenCredentials struct {
+	opts TokenOptions
+
+	mu     sync.Mutex
+	cached string
+	stamp  filestamp.Stamp
+}
+
+func (c *tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (c *tokenCredentials) RequireTransportSecurity() bool {
+	return !c.opts.AllowInsecure
+}
+
+// token returns the configured token, re-reading the token file if it has
+// changed since the last call.
+func (c *tokenCredentials) token() (string, error) {
+	if c.opts.File == "" {
+		if c.opts.Token == "" {
+			return "", errors.New("auth token is empty")
+		}
+		return c.opts.Token, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stamp, err := filestamp.Stat(c.opts.File)
+	if err != nil {
+		return "", fmt.Errorf("reading auth token file: %w", err)
+	}
+	if c.cached != "" && stamp == c.stamp {
+		return c.cached, nil
+	}
+
+	data, err := os.ReadFile(c.opts.File)
+	if err != nil {
+		return "", fmt.Errorf("reading auth token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("auth token file %s is empty", c.opts.File)
+	}
+
+	c.cached, c.stamp = token, stamp
+	return token, nil
+}
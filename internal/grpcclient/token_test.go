@@ -0,0 +1,145 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// startAuthServer runs an in-process plaintext gRPC server that rejects calls
+// without "Bearer <want>" and returns its address.
+func startAuthServer(t *testing.T, want *string) string {
+	t.Helper()
+
+	check := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer "+*want {
+			return status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return nil
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := check(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+	)
+	disastersv1.RegisterDisasterServiceServer(srv, disasterServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func callWithToken(t *testing.T, addr string, creds credentials.PerRPCCredentials) error {
+	t.Helper()
+
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(creds),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = disastersv1.NewDisasterServiceClient(conn).GetDisaster(ctx, &disastersv1.GetDisasterRequest{Id: "eq-1"})
+	return err
+}
+
+func TestNewTokenCredentials(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "token", []byte("from-file\n"))
+
+	tests := []struct {
+		name    string
+		opts    TokenOptions
+		want    string
+		wantErr bool
+	}{
+		{"static token", TokenOptions{Token: "static"}, "Bearer static", false},
+		{"file takes precedence", TokenOptions{Token: "static", File: file}, "Bearer from-file", false},
+		{"no token", TokenOptions{}, "", true},
+		{"missing file", TokenOptions{File: filepath.Join(dir, "missing")}, "", true},
+		{"empty file", TokenOptions{File: writeFile(t, dir, "empty", []byte("\n"))}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := NewTokenCredentials(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTokenCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			md, err := creds.GetRequestMetadata(context.Background())
+			if err != nil {
+				t.Fatalf("GetRequestMetadata() error = %v", err)
+			}
+			if md["authorization"] != tt.want {
+				t.Errorf("authorization = %q, want %q", md["authorization"], tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTokenCredentials_RequireTransportSecurity(t *testing.T) {
+	secure, _ := NewTokenCredentials(TokenOptions{Token: "t"})
+	if !secure.RequireTransportSecurity() {
+		t.Error("RequireTransportSecurity() = false, want true by default")
+	}
+
+	plaintext, _ := NewTokenCredentials(TokenOptions{Token: "t", AllowInsecure: true})
+	if plaintext.RequireTransportSecurity() {
+		t.Error("RequireTransportSecurity() = true, want false with AllowInsecure")
+	}
+}
+
+func TestNewTokenCredentials_ReloadsRotatedFile(t *testing.T) {
+	want := "first"
+	addr := startAuthServer(t, &want)
+
+	dir := t.TempDir()
+	writeFile(t, dir, "token", []byte("first"))
+
+	creds, err := NewTokenCredentials(TokenOptions{File: filepath.Join(dir, "token"), AllowInsecure: true})
+	if err != nil {
+		t.Fatalf("NewTokenCredentials() error = %v", err)
+	}
+	if err := callWithToken(t, addr, creds); err != nil {
+		t.Fatalf("call error = %v, want authenticated", err)
+	}
+
+	// The server rotates first; calls fail until the file catches up
+	want = "second"
+	if err := callWithToken(t, addr, creds); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("call error = %v, want Unauthenticated", err)
+	}
+
+	writeFile(t, dir, "token", []byte("second"))
+	if err := callWithToken(t, addr, creds); err != nil {
+		t.Errorf("call after rotation error = %v, want the new token sent", err)
+	}
+}
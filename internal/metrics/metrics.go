@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "disaster_alerts_bot"
+
+// Reasons a disaster is filtered out, for DisastersFiltered.
+const (
+	ReasonMagnitude  = "magnitude"   // Below the type's minimum magnitude
+	ReasonPopulation = "population"  // Below the type's minimum affected population
+	ReasonAlertLevel = "alert_level" // Below the type's minimum alert level
+	ReasonNoChannel  = "no_channel"  // Passed the filter but no channel wants it
+)
+
+var (
+	DisastersReceived = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "disasters_received_total",
+		Help:      "Disasters received from the stream, startup fetch and gap fills, counting a payload re-fetched by a gap fill once.",
+	})
+
+	DisastersFiltered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "disasters_filtered_total",
+		Help:      "New disasters not posted to any channel, by reason.",
+	}, []string{"reason"})
+
+	MessagesPosted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_posted_total",
+		Help:      "Disaster alerts posted to Discord, one per channel.",
+	})
+
+	PostFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "post_failures_total",
+		Help:      "Failed Discord posts, by whether they will be retried.",
+	}, []string{"kind"})
+
+	AckFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ack_failures_total",
+		Help:      "Failed AcknowledgeDisasters requests.",
+	})
+
+	StreamReconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_reconnects_total",
+		Help:      "Disaster stream reconnects, by cause.",
+	}, []string{"cause"})
+
+	PostLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "post_latency_seconds",
+		Help:      "Time taken by a Discord post request, including rate limit waits.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10), // 50ms to ~25s
+	})
+
+	DeliveryDelay = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "delivery_delay_seconds",
+		Help:      "Time from a disaster's timestamp to its alert being posted.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 12), // 30s to ~17h
+	})
+)
+
+// Registry holds the bot's metrics along with Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		DisastersReceived,
+		DisastersFiltered,
+		MessagesPosted,
+		PostFailures,
+		AckFailures,
+		StreamReconnects,
+		PostLatency,
+		DeliveryDelay,
+	)
+}
+
+// Handler serves Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	DisastersFiltered.WithLabelValues(ReasonMagnitude).Inc()
+	StreamReconnects.WithLabelValues("idle").Inc()
+	PostLatency.Observe(0.2)
+
+	srv := httptest.NewServer(Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET /metrics error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, want := range []string{
+		`disaster_alerts_bot_disasters_received_total 0`,
+		`disaster_alerts_bot_disasters_filtered_total{reason="magnitude"} 1`,
+		`disaster_alerts_bot_stream_reconnects_total{cause="idle"} 1`,
+		`disaster_alerts_bot_post_latency_seconds_count 1`,
+		`disaster_alerts_bot_delivery_delay_seconds_bucket`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package rules compiles filter expressions over disasters. Rules are written
+// in CEL (https://cel.dev) over the variables type, alert_level, magnitude,
+// population, lat, lon, source, title and country. Disaster type and alert
+// level names are constants, e.g.
+//
+//	type == FLOOD && population > 1000000 || alert_level == RED
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// env declares the variables and constants; it is shared by every rule.
+var env = sync.OnceValues(func() (*cel.Env, error) {
+	opts := []cel.EnvOption{
+		cel.Variable("type", cel.IntType),
+		cel.Variable("alert_level", cel.IntType),
+		cel.Variable("magnitude", cel.DoubleType),
+		cel.Variable("population", cel.IntType),
+		cel.Variable("lat", cel.DoubleType),
+		cel.Variable("lon", cel.DoubleType),
+		cel.Variable("source", cel.StringType),
+		cel.Variable("title", cel.StringType),
+		cel.Variable("country", cel.StringType),
+		cel.CrossTypeNumericComparisons(true),
+	}
+	for val, name := range disastersv1.DisasterType_name {
+		if val != 0 {
+			opts = append(opts, cel.Constant(name, cel.IntType, types.Int(val)))
+		}
+	}
+	for val, name := range disastersv1.AlertLevel_name {
+		opts = append(opts, cel.Constant(name, cel.IntType, types.Int(val)))
+	}
+	return cel.NewEnv(opts...)
+})
+
+// Rule is a compiled expression deciding whether a disaster matches.
+type Rule struct {
+	expr    string
+	program cel.Program
+}
+
+// Compile parses and type-checks expr, which must evaluate to a bool.
+func Compile(expr string) (*Rule, error) {
+	e, err := env()
+	if err != nil {
+		return nil, fmt.Errorf("creating rule environment: %w", err)
+	}
+	if expr == "" {
+		return nil, errors.New("empty rule")
+	}
+
+	ast, issues := e.Compile(expr)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) {
+		return nil, fmt.Errorf("rule must evaluate to bool, got %s", ast.OutputType())
+	}
+	program, err := e.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Rule{expr: expr, program: program}, nil
+}
+
+// String returns the expression the rule was compiled from.
+func (r *Rule) String() string {
+	return r.expr
+}
+
+// Match evaluates the rule against d. It fails if evaluation does, e.g. on
+// division by zero.
+func (r *Rule) Match(d *disastersv1.Disaster) (bool, error) {
+	out, _, err := r.program.Eval(map[string]any{
+		"type":        int64(d.Type),
+		"alert_level": int64(d.AlertLevel),
+		"magnitude":   d.Magnitude,
+		"population":  d.AffectedPopulationCount,
+		"lat":         d.Latitude,
+		"lon":         d.Longitude,
+		"source":      d.Source,
+		"title":       d.Title,
+		"country":     d.Country,
+	})
+	if err != nil {
+		return false, err
+	}
+	match, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("rule returned %v, not a bool", out)
+	}
+	return match, nil
+}
@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"testing"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+func TestRule_Match(t *testing.T) {
+	flood := &disastersv1.Disaster{
+		Type:                    disastersv1.DisasterType_FLOOD,
+		AlertLevel:              disastersv1.AlertLevel_ORANGE,
+		AffectedPopulationCount: 2000000,
+		Latitude:                27.7,
+		Longitude:               85.3,
+		Source:                  "GDACS",
+		Title:                   "Floods in Nepal",
+		Country:                 "Nepal",
+	}
+	quake := &disastersv1.Disaster{
+		Type:       disastersv1.DisasterType_EARTHQUAKE,
+		AlertLevel: disastersv1.AlertLevel_RED,
+		Magnitude:  7.1,
+		Latitude:   35.7,
+		Longitude:  139.7,
+		Source:     "USGS",
+		Title:      "M 7.1 - Honshu, Japan",
+		Country:    "Japan",
+	}
+
+	// Floods in South Asia with more than 1M affected, or anything RED
+	southAsia := "type == FLOOD && population > 1000000 && lat >= 5 && lat <= 38 && lon >= 60 && lon <= 98 || alert_level == RED"
+
+	tests := []struct {
+		name string
+		expr string
+		d    *disastersv1.Disaster
+		want bool
+	}{
+		{"region rule matches flood", southAsia, flood, true},
+		{"region rule matches RED", southAsia, quake, true},
+		{"region rule rejects small flood", southAsia, &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AffectedPopulationCount: 1000, Latitude: 27.7, Longitude: 85.3}, false},
+		{"alert level ordering", "alert_level >= ORANGE", flood, true},
+		{"magnitude", "magnitude >= 7.0", quake, true},
+		{"magnitude below", "magnitude >= 7.5", quake, false},
+		{"int against double", "magnitude > 7", quake, true},
+		{"source", `source == "USGS"`, flood, false},
+		{"title", `title.contains("Nepal")`, flood, true},
+		{"country list", `country in ["India", "Nepal", "Bangladesh"]`, flood, true},
+		{"zero disaster", "type == EARTHQUAKE || alert_level != UNKNOWN", &disastersv1.Disaster{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile(%q) error = %v", tt.expr, err)
+			}
+			got, err := rule.Match(tt.d)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"empty", ""},
+		{"syntax error", "type =="},
+		{"unknown variable", "depth > 10"},
+		{"unknown constant", "type == METEOR"},
+		{"type names are not strings", `type == "FLOOD"`},
+		{"not a bool", "magnitude * 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile(tt.expr); err == nil {
+				t.Errorf("Compile(%q) error = nil, want an error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestRule_MatchError(t *testing.T) {
+	rule, err := Compile("population / 0 > 1")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := rule.Match(&disastersv1.Disaster{}); err == nil {
+		t.Error("Match() error = nil, want division by zero")
+	}
+}
+
+func TestRule_String(t *testing.T) {
+	expr := "alert_level == RED"
+	rule, err := Compile(expr)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if rule.String() != expr {
+		t.Errorf("String() = %q, want %q", rule.String(), expr)
+	}
+}
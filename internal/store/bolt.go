@@ -0,0 +1,218 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	recordsBucket = []byte("records")
+	outboxBucket  = []byte("outbox")
+)
+
+// Bolt is a Store backed by an embedded BoltDB file.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens or creates the database at path. Only one process can hold
+// the file open at a time.
+func OpenBolt(path string) (*Bolt, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating store dir: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening store: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{recordsBucket, outboxBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating buckets: %w", err)
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+func (b *Bolt) Get(id string) (*Record, error) {
+	var rec *Record
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(recordsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		rec = &Record{}
+		return json.Unmarshal(data, rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (b *Bolt) Put(rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding record: %w", err)
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordsBucket).Put([]byte(rec.DisasterID), data)
+	})
+}
+
+func (b *Bolt) Unacked() ([]*Record, error) {
+	var out []*Record
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordsBucket).ForEach(func(_, data []byte) error {
+			rec := &Record{}
+			if err := json.Unmarshal(data, rec); err != nil {
+				return err
+			}
+			if !rec.Acked {
+				out = append(out, rec)
+			}
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (b *Bolt) MarkAcked(ids []string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket)
+		for _, id := range ids {
+			data := bucket.Get([]byte(id))
+			if data == nil {
+				continue
+			}
+
+			rec := &Record{}
+			if err := json.Unmarshal(data, rec); err != nil {
+				return err
+			}
+			rec.Acked = true
+
+			data, err := json.Marshal(rec)
+			if err != nil {
+				return fmt.Errorf("encoding record: %w", err)
+			}
+			if err := bucket.Put([]byte(id), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *Bolt) Prune(cutoff time.Time) (int, error) {
+	pruned := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket)
+
+		// Collect first: deleting under a cursor skips the following key
+		var expired [][]byte
+		err := bucket.ForEach(func(k, data []byte) error {
+			rec := &Record{}
+			if err := json.Unmarshal(data, rec); err != nil {
+				return err
+			}
+			if rec.Acked && rec.UpdatedAt.Before(cutoff) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(expired)
+		return nil
+	})
+	return pruned, err
+}
+
+func (b *Bolt) GetOutbox(id string) (*OutboxEntry, error) {
+	var e *OutboxEntry
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(outboxBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		e = &OutboxEntry{}
+		return json.Unmarshal(data, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (b *Bolt) PutOutbox(e *OutboxEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding outbox entry: %w", err)
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).Put([]byte(e.DisasterID), data)
+	})
+}
+
+func (b *Bolt) Outbox() ([]*OutboxEntry, error) {
+	var out []*OutboxEntry
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).ForEach(func(_, data []byte) error {
+			e := &OutboxEntry{}
+			if err := json.Unmarshal(data, e); err != nil {
+				return err
+			}
+			out = append(out, e)
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (b *Bolt) DeleteOutbox(id string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(outboxBucket).Delete([]byte(id))
+	})
+}
+
+func (b *Bolt) Complete(rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding record: %w", err)
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(recordsBucket).Put([]byte(rec.DisasterID), data); err != nil {
+			return err
+		}
+		return tx.Bucket(outboxBucket).Delete([]byte(rec.DisasterID))
+	})
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
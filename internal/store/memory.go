@@ -0,0 +1,133 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory is a Store that keeps records in memory only.
+type Memory struct {
+	records map[string]Record
+	outbox  map[string]OutboxEntry
+	mu      sync.RWMutex
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		records: make(map[string]Record),
+		outbox:  make(map[string]OutboxEntry),
+	}
+}
+
+func (m *Memory) Get(id string) (*Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rec, ok := m.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return rec.clone(), nil
+}
+
+func (m *Memory) Put(rec *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[rec.DisasterID] = *rec.clone()
+	return nil
+}
+
+func (m *Memory) Unacked() ([]*Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var out []*Record
+	for _, rec := range m.records {
+		if !rec.Acked {
+			out = append(out, rec.clone())
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) MarkAcked(ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		if rec, ok := m.records[id]; ok {
+			rec.Acked = true
+			m.records[id] = rec
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Prune(cutoff time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pruned := 0
+	for id, rec := range m.records {
+		if rec.Acked && rec.UpdatedAt.Before(cutoff) {
+			delete(m.records, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+func (m *Memory) GetOutbox(id string) (*OutboxEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, ok := m.outbox[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return e.clone(), nil
+}
+
+func (m *Memory) PutOutbox(e *OutboxEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outbox[e.DisasterID] = *e.clone()
+	return nil
+}
+
+func (m *Memory) Outbox() ([]*OutboxEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]*OutboxEntry, 0, len(m.outbox))
+	for _, e := range m.outbox {
+		out = append(out, e.clone())
+	}
+	return out, nil
+}
+
+func (m *Memory) DeleteOutbox(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.outbox, id)
+	return nil
+}
+
+func (m *Memory) Complete(rec *Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[rec.DisasterID] = *rec.clone()
+	delete(m.outbox, rec.DisasterID)
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// clone copies the record so callers can't mutate stored state.
+func (r *Record) clone() *Record {
+	c := *r
+	c.Messages = append([]Message(nil), r.Messages...)
+	return &c
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// OutboxEntry is a disaster queued for delivery. It stays in the outbox until
+// every route has been posted to, and keeps the messages already sent so a
+// retry only posts to the routes that failed.
+type OutboxEntry struct {
+	DisasterID  string    `json:"disaster_id"`
+	Payload     []byte    `json:"payload"`
+	Messages    []Message `json:"messages,omitempty"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewOutboxEntry(d *disastersv1.Disaster) (*OutboxEntry, error) {
+	e := &OutboxEntry{DisasterID: d.Id, CreatedAt: time.Now()}
+	if err := e.SetDisaster(d); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// SetDisaster replaces the queued payload, e.g. when the disaster is updated
+// upstream before it could be delivered.
+func (e *OutboxEntry) SetDisaster(d *disastersv1.Disaster) error {
+	data, err := proto.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("encoding disaster: %w", err)
+	}
+	e.Payload = data
+	return nil
+}
+
+func (e *OutboxEntry) Disaster() (*disastersv1.Disaster, error) {
+	d := &disastersv1.Disaster{}
+	if err := proto.Unmarshal(e.Payload, d); err != nil {
+		return nil, fmt.Errorf("decoding disaster: %w", err)
+	}
+	return d, nil
+}
+
+func (e *OutboxEntry) clone() *OutboxEntry {
+	c := *e
+	c.Payload = append([]byte(nil), e.Payload...)
+	c.Messages = append([]Message(nil), e.Messages...)
+	return &c
+}
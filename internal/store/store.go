@@ -0,0 +1,79 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+// Record is the posted state of a disaster: where it was posted, the values
+// it was last rendered with, and whether it was acknowledged upstream.
+type Record struct {
+	DisasterID  string                 `json:"disaster_id"`
+	Messages    []Message              `json:"messages"`
+	PayloadHash string                 `json:"payload_hash"`
+	Acked       bool                   `json:"acked"`
+	AlertLevel  disastersv1.AlertLevel `json:"alert_level"`
+	Population  int64                  `json:"population"`
+	Magnitude   float64                `json:"magnitude"`
+
+	// AlertedPopulation is the affected population when the disaster was last
+	// posted or escalated, the baseline for population escalations.
+	AlertedPopulation int64 `json:"alerted_population"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Message is a Discord message posted for a disaster. Distance is the
+// rendered geofence distance for the route it was posted through, if any.
+type Message struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+	Distance  string `json:"distance,omitempty"`
+}
+
+// Store persists posted-message state and the delivery outbox across
+// restarts.
+type Store interface {
+	// Get returns the record for a disaster ID, or ErrNotFound.
+	Get(id string) (*Record, error)
+	Put(rec *Record) error
+	// Unacked returns records that were posted but not acknowledged upstream.
+	Unacked() ([]*Record, error)
+	// MarkAcked flags the records for the given disaster IDs as acknowledged.
+	// Unknown IDs are ignored.
+	MarkAcked(ids []string) error
+	// Prune deletes acknowledged records last updated before cutoff and
+	// returns how many were deleted. Unacknowledged records are kept, since
+	// upstream still reports them unsent and they would be posted again.
+	Prune(cutoff time.Time) (int, error)
+
+	// GetOutbox returns the queued delivery for a disaster ID, or ErrNotFound.
+	GetOutbox(id string) (*OutboxEntry, error)
+	PutOutbox(e *OutboxEntry) error
+	// Outbox returns every queued delivery.
+	Outbox() ([]*OutboxEntry, error)
+	DeleteOutbox(id string) error
+	// Complete saves the record for a delivered disaster and removes it from
+	// the outbox in one step.
+	Complete(rec *Record) error
+
+	Close() error
+}
+
+// PayloadHash returns a stable hash of a disaster's contents, used to skip
+// re-deliveries that carry nothing new.
+func PayloadHash(d *disastersv1.Disaster) string {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(d)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
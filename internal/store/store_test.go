@@ -0,0 +1,219 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+)
+
+// testStores runs fn against every Store implementation.
+func testStores(t *testing.T, fn func(t *testing.T, s Store)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewMemory())
+	})
+	t.Run("bolt", func(t *testing.T) {
+		s, err := OpenBolt(filepath.Join(t.TempDir(), "state.db"))
+		if err != nil {
+			t.Fatalf("OpenBolt() error = %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		fn(t, s)
+	})
+}
+
+func TestStore_GetPut(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		if _, err := s.Get("missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+		}
+
+		rec := &Record{
+			DisasterID:  "eq-1",
+			Messages:    []Message{{ChannelID: "111", MessageID: "m-1", Distance: "Inside Java"}},
+			PayloadHash: "abc",
+			AlertLevel:  disastersv1.AlertLevel_RED,
+			Population:  1000,
+			Magnitude:   6.5,
+			UpdatedAt:   time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+		}
+		if err := s.Put(rec); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+
+		// Mutating the caller's copy must not change the stored record
+		rec.Messages[0].MessageID = "changed"
+
+		got, err := s.Get("eq-1")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.Messages[0].MessageID != "m-1" || got.AlertLevel != disastersv1.AlertLevel_RED || got.Magnitude != 6.5 {
+			t.Errorf("Get() = %+v, want stored record", got)
+		}
+		if !got.UpdatedAt.Equal(rec.UpdatedAt) {
+			t.Errorf("UpdatedAt = %v, want %v", got.UpdatedAt, rec.UpdatedAt)
+		}
+	})
+}
+
+func TestStore_Unacked(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		_ = s.Put(&Record{DisasterID: "acked", Acked: true})
+		_ = s.Put(&Record{DisasterID: "pending"})
+
+		recs, err := s.Unacked()
+		if err != nil {
+			t.Fatalf("Unacked() error = %v", err)
+		}
+		if len(recs) != 1 || recs[0].DisasterID != "pending" {
+			t.Errorf("Unacked() = %+v, want only pending", recs)
+		}
+	})
+}
+
+func TestStore_MarkAcked(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		_ = s.Put(&Record{DisasterID: "eq-1", Messages: []Message{{ChannelID: "111", MessageID: "m-1"}}})
+		_ = s.Put(&Record{DisasterID: "eq-2"})
+
+		if err := s.MarkAcked([]string{"eq-1", "missing"}); err != nil {
+			t.Fatalf("MarkAcked() error = %v", err)
+		}
+
+		got, _ := s.Get("eq-1")
+		if !got.Acked || len(got.Messages) != 1 {
+			t.Errorf("Get(eq-1) = %+v, want acked with messages kept", got)
+		}
+		if recs, _ := s.Unacked(); len(recs) != 1 || recs[0].DisasterID != "eq-2" {
+			t.Errorf("Unacked() = %+v, want only eq-2", recs)
+		}
+		if _, err := s.Get("missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestStore_Prune(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		now := time.Now()
+		for _, id := range []string{"old-1", "old-2", "old-3"} {
+			_ = s.Put(&Record{DisasterID: id, Acked: true, UpdatedAt: now.Add(-48 * time.Hour)})
+		}
+		_ = s.Put(&Record{DisasterID: "new", Acked: true, UpdatedAt: now})
+		_ = s.Put(&Record{DisasterID: "old-unacked", UpdatedAt: now.Add(-48 * time.Hour)})
+
+		n, err := s.Prune(now.Add(-24 * time.Hour))
+		if err != nil {
+			t.Fatalf("Prune() error = %v", err)
+		}
+		if n != 3 {
+			t.Errorf("Prune() = %d, want 3", n)
+		}
+		if _, err := s.Get("new"); err != nil {
+			t.Errorf("Get(new) error = %v, want record kept", err)
+		}
+		if _, err := s.Get("old-2"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(old-2) error = %v, want ErrNotFound", err)
+		}
+		if _, err := s.Get("old-unacked"); err != nil {
+			t.Errorf("Get(old-unacked) error = %v, want unacknowledged record kept", err)
+		}
+	})
+}
+
+func TestBolt_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	s, err := OpenBolt(path)
+	if err != nil {
+		t.Fatalf("OpenBolt() error = %v", err)
+	}
+	_ = s.Put(&Record{DisasterID: "eq-1", Messages: []Message{{ChannelID: "111", MessageID: "m-1"}}})
+	s.Close()
+
+	s, err = OpenBolt(path)
+	if err != nil {
+		t.Fatalf("OpenBolt() error = %v", err)
+	}
+	defer s.Close()
+
+	got, err := s.Get("eq-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].MessageID != "m-1" {
+		t.Errorf("Get() = %+v, want persisted message", got)
+	}
+}
+
+func TestPayloadHash(t *testing.T) {
+	a := &disastersv1.Disaster{Id: "eq-1", AlertLevel: disastersv1.AlertLevel_ORANGE}
+	b := &disastersv1.Disaster{Id: "eq-1", AlertLevel: disastersv1.AlertLevel_ORANGE}
+	c := &disastersv1.Disaster{Id: "eq-1", AlertLevel: disastersv1.AlertLevel_RED}
+
+	if PayloadHash(a) != PayloadHash(b) {
+		t.Error("PayloadHash() differs for equal disasters")
+	}
+	if PayloadHash(a) == PayloadHash(c) {
+		t.Error("PayloadHash() equal for different disasters")
+	}
+}
+
+func TestStore_Outbox(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		e, err := NewOutboxEntry(&disastersv1.Disaster{Id: "eq-1", Title: "Earthquake"})
+		if err != nil {
+			t.Fatalf("NewOutboxEntry() error = %v", err)
+		}
+		e.Attempts = 2
+		if err := s.PutOutbox(e); err != nil {
+			t.Fatalf("PutOutbox() error = %v", err)
+		}
+
+		got, err := s.GetOutbox("eq-1")
+		if err != nil {
+			t.Fatalf("GetOutbox() error = %v", err)
+		}
+		d, err := got.Disaster()
+		if err != nil {
+			t.Fatalf("Disaster() error = %v", err)
+		}
+		if d.Title != "Earthquake" || got.Attempts != 2 {
+			t.Errorf("GetOutbox() = %+v (%v), want queued entry", got, d)
+		}
+
+		entries, err := s.Outbox()
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("Outbox() = %v, %v, want one entry", entries, err)
+		}
+
+		if err := s.DeleteOutbox("eq-1"); err != nil {
+			t.Fatalf("DeleteOutbox() error = %v", err)
+		}
+		if _, err := s.GetOutbox("eq-1"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetOutbox() after delete error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestStore_Complete(t *testing.T) {
+	testStores(t, func(t *testing.T, s Store) {
+		e, _ := NewOutboxEntry(&disastersv1.Disaster{Id: "eq-1"})
+		_ = s.PutOutbox(e)
+
+		if err := s.Complete(&Record{DisasterID: "eq-1", Messages: []Message{{ChannelID: "111", MessageID: "m-1"}}}); err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+
+		if _, err := s.GetOutbox("eq-1"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetOutbox() error = %v, want entry removed", err)
+		}
+		rec, err := s.Get("eq-1")
+		if err != nil || len(rec.Messages) != 1 {
+			t.Errorf("Get() = %+v, %v, want completed record", rec, err)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package subscriptions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/geo"
+)
+
+// Subscription routes disasters matching its filters into a Discord channel.
+// A zero Type matches every disaster type, a zero MinAlertLevel matches every
+// alert level and a nil Area matches every location.
+type Subscription struct {
+	ChannelID     string
+	GuildID       string
+	Type          disastersv1.DisasterType
+	MinAlertLevel disastersv1.AlertLevel
+	Area          geo.Area
+}
+
+func (s Subscription) Matches(d *disastersv1.Disaster) bool {
+	if s.Type != disastersv1.DisasterType_UNSPECIFIED && d.Type != s.Type {
+		return false
+	}
+	if d.AlertLevel < s.MinAlertLevel {
+		return false
+	}
+	return s.Area == nil || s.Area.Contains(geo.Point{Lat: d.Latitude, Lon: d.Longitude})
+}
+
+// subscriptionJSON is the on-disk form, with enums stored by name so the file
+// stays readable and survives enum renumbering.
+type subscriptionJSON struct {
+	ChannelID     string    `json:"channel_id"`
+	GuildID       string    `json:"guild_id,omitempty"`
+	Type          string    `json:"type,omitempty"`
+	MinAlertLevel string    `json:"min_alert_level,omitempty"`
+	Area          *areaJSON `json:"area,omitempty"`
+}
+
+// areaJSON is either a circle (RadiusKm > 0) or a reference by name to a
+// polygon from the geofence file.
+type areaJSON struct {
+	Name     string  `json:"name"`
+	Lat      float64 `json:"lat,omitempty"`
+	Lon      float64 `json:"lon,omitempty"`
+	RadiusKm float64 `json:"radius_km,omitempty"`
+}
+
+func (s Subscription) toJSON() subscriptionJSON {
+	out := subscriptionJSON{ChannelID: s.ChannelID, GuildID: s.GuildID}
+	if s.Type != disastersv1.DisasterType_UNSPECIFIED {
+		out.Type = s.Type.String()
+	}
+	if s.MinAlertLevel != disastersv1.AlertLevel_UNKNOWN {
+		out.MinAlertLevel = s.MinAlertLevel.String()
+	}
+	switch area := s.Area.(type) {
+	case geo.Circle:
+		out.Area = &areaJSON{Name: area.Label, Lat: area.Center.Lat, Lon: area.Center.Lon, RadiusKm: area.RadiusKm}
+	case *geo.Polygon:
+		out.Area = &areaJSON{Name: area.Label}
+	}
+	return out
+}
+
+func (in subscriptionJSON) toSubscription(polygons map[string]*geo.Polygon) (Subscription, error) {
+	s := Subscription{ChannelID: in.ChannelID, GuildID: in.GuildID}
+	if in.Type != "" {
+		val, ok := disastersv1.DisasterType_value[in.Type]
+		if !ok {
+			return s, fmt.Errorf("unknown disaster type %q", in.Type)
+		}
+		s.Type = disastersv1.DisasterType(val)
+	}
+	if in.MinAlertLevel != "" {
+		val, ok := disastersv1.AlertLevel_value[in.MinAlertLevel]
+		if !ok {
+			return s, fmt.Errorf("unknown alert level %q", in.MinAlertLevel)
+		}
+		s.MinAlertLevel = disastersv1.AlertLevel(val)
+	}
+	if in.Area != nil {
+		if in.Area.RadiusKm > 0 {
+			s.Area = geo.Circle{
+				Label:    in.Area.Name,
+				Center:   geo.Point{Lat: in.Area.Lat, Lon: in.Area.Lon},
+				RadiusKm: in.Area.RadiusKm,
+			}
+		} else {
+			polygon, ok := polygons[in.Area.Name]
+			if !ok {
+				return s, fmt.Errorf("unknown area %q", in.Area.Name)
+			}
+			s.Area = polygon
+		}
+	}
+	return s, nil
+}
+
+// Store holds subscriptions in memory and persists them to a JSON file on
+// every change.
+type Store struct {
+	path string
+	subs []Subscription
+	mu   sync.RWMutex
+}
+
+// Load reads subscriptions from path. A missing file yields an empty store and
+// an empty path keeps subscriptions in memory only. Polygon areas are resolved
+// by name against polygons.
+func Load(path string, polygons map[string]*geo.Polygon) (*Store, error) {
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if path == "" || errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading subscriptions: %w", err)
+	}
+
+	var stored []subscriptionJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("parsing subscriptions: %w", err)
+	}
+
+	for _, in := range stored {
+		sub, err := in.toSubscription(polygons)
+		if err != nil {
+			return nil, fmt.Errorf("subscription for channel %s: %w", in.ChannelID, err)
+		}
+		s.subs = append(s.subs, sub)
+	}
+	return s, nil
+}
+
+// Add stores sub, replacing any existing subscription for the same channel,
+// disaster type and area name.
+func (s *Store) Add(sub Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := slices.DeleteFunc(slices.Clone(s.subs), func(existing Subscription) bool {
+		return existing.ChannelID == sub.ChannelID && existing.Type == sub.Type && areaName(existing.Area) == areaName(sub.Area)
+	})
+	subs = append(subs, sub)
+
+	return s.replace(subs)
+}
+
+// Remove deletes the channel's subscription for typ, or all of the channel's
+// subscriptions if typ is UNSPECIFIED. It returns the number removed.
+func (s *Store) Remove(channelID string, typ disastersv1.DisasterType) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := slices.DeleteFunc(slices.Clone(s.subs), func(existing Subscription) bool {
+		return existing.ChannelID == channelID && (typ == disastersv1.DisasterType_UNSPECIFIED || existing.Type == typ)
+	})
+	removed := len(s.subs) - len(subs)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	return removed, s.replace(subs)
+}
+
+// List returns the subscriptions for a channel.
+func (s *Store) List(channelID string) []Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Subscription
+	for _, sub := range s.subs {
+		if sub.ChannelID == channelID {
+			out = append(out, sub)
+		}
+	}
+	return out
+}
+
+// Match returns the subscriptions that d should be routed to. It is safe to
+// call on a nil Store.
+func (s *Store) Match(d *disastersv1.Disaster) []Subscription {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Subscription
+	for _, sub := range s.subs {
+		if sub.Matches(d) {
+			out = append(out, sub)
+		}
+	}
+	return out
+}
+
+func areaName(a geo.Area) string {
+	if a == nil {
+		return ""
+	}
+	return a.Name()
+}
+
+// replace persists subs and swaps them in. Caller must hold s.mu.
+func (s *Store) replace(subs []Subscription) error {
+	if err := s.save(subs); err != nil {
+		return err
+	}
+	s.subs = subs
+	return nil
+}
+
+// save writes subs to a temp file and renames it over the store file so a
+// crash mid-write never leaves a truncated file behind.
+func (s *Store) save(subs []Subscription) error {
+	if s.path == "" {
+		return nil
+	}
+
+	stored := make([]subscriptionJSON, len(subs))
+	for i, sub := range subs {
+		stored[i] = sub.toJSON()
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding subscriptions: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating subscriptions dir: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing subscriptions: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing subscriptions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package subscriptions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	disastersv1 "github.com/mr1hm/go-disaster-alerts/gen/disasters/v1"
+
+	"github.com/mr1hm/disaster-alerts-bot/internal/geo"
+)
+
+func TestSubscription_Matches(t *testing.T) {
+	tests := []struct {
+		name     string
+		sub      Subscription
+		disaster *disastersv1.Disaster
+		want     bool
+	}{
+		{
+			name:     "any type any level",
+			sub:      Subscription{},
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_CYCLONE},
+			want:     true,
+		},
+		{
+			name:     "type matches at level",
+			sub:      Subscription{Type: disastersv1.DisasterType_FLOOD, MinAlertLevel: disastersv1.AlertLevel_RED},
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_RED},
+			want:     true,
+		},
+		{
+			name:     "type matches below level",
+			sub:      Subscription{Type: disastersv1.DisasterType_FLOOD, MinAlertLevel: disastersv1.AlertLevel_RED},
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE},
+			want:     false,
+		},
+		{
+			name:     "type mismatch",
+			sub:      Subscription{Type: disastersv1.DisasterType_FLOOD},
+			disaster: &disastersv1.Disaster{Type: disastersv1.DisasterType_EARTHQUAKE, AlertLevel: disastersv1.AlertLevel_RED},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.Matches(tt.disaster); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "subscriptions.json")
+
+	store, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	flood := Subscription{ChannelID: "111", GuildID: "g", Type: disastersv1.DisasterType_FLOOD, MinAlertLevel: disastersv1.AlertLevel_RED}
+	everything := Subscription{ChannelID: "222"}
+	if err := store.Add(flood); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := store.Add(everything); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading store file: %v", err)
+	}
+	if !strings.Contains(string(data), `"type": "FLOOD"`) {
+		t.Errorf("store file should contain enum names, got %s", data)
+	}
+
+	reloaded, err := Load(path, nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := reloaded.List("111"); len(got) != 1 || got[0] != flood {
+		t.Errorf("List(111) = %+v, want [%+v]", got, flood)
+	}
+	if got := reloaded.List("222"); len(got) != 1 || got[0] != everything {
+		t.Errorf("List(222) = %+v, want [%+v]", got, everything)
+	}
+}
+
+func TestStore_AddReplacesSameType(t *testing.T) {
+	store, _ := Load("", nil)
+
+	_ = store.Add(Subscription{ChannelID: "111", Type: disastersv1.DisasterType_FLOOD, MinAlertLevel: disastersv1.AlertLevel_GREEN})
+	_ = store.Add(Subscription{ChannelID: "111", Type: disastersv1.DisasterType_FLOOD, MinAlertLevel: disastersv1.AlertLevel_RED})
+	_ = store.Add(Subscription{ChannelID: "111", Type: disastersv1.DisasterType_CYCLONE})
+
+	subs := store.List("111")
+	if len(subs) != 2 {
+		t.Fatalf("List() len = %d, want 2", len(subs))
+	}
+	for _, sub := range subs {
+		if sub.Type == disastersv1.DisasterType_FLOOD && sub.MinAlertLevel != disastersv1.AlertLevel_RED {
+			t.Errorf("FLOOD MinAlertLevel = %v, want RED", sub.MinAlertLevel)
+		}
+	}
+}
+
+func TestStore_Remove(t *testing.T) {
+	store, _ := Load("", nil)
+
+	_ = store.Add(Subscription{ChannelID: "111", Type: disastersv1.DisasterType_FLOOD})
+	_ = store.Add(Subscription{ChannelID: "111", Type: disastersv1.DisasterType_CYCLONE})
+	_ = store.Add(Subscription{ChannelID: "222", Type: disastersv1.DisasterType_FLOOD})
+
+	n, err := store.Remove("111", disastersv1.DisasterType_FLOOD)
+	if err != nil || n != 1 {
+		t.Fatalf("Remove(111, FLOOD) = %d, %v, want 1, nil", n, err)
+	}
+
+	n, err = store.Remove("111", disastersv1.DisasterType_UNSPECIFIED)
+	if err != nil || n != 1 {
+		t.Fatalf("Remove(111, UNSPECIFIED) = %d, %v, want 1, nil", n, err)
+	}
+
+	if got := store.List("111"); len(got) != 0 {
+		t.Errorf("List(111) = %+v, want empty", got)
+	}
+	if got := store.List("222"); len(got) != 1 {
+		t.Errorf("List(222) = %+v, want 1 subscription", got)
+	}
+}
+
+func TestStore_Match(t *testing.T) {
+	var nilStore *Store
+	if got := nilStore.Match(&disastersv1.Disaster{}); got != nil {
+		t.Errorf("nil Store Match() = %+v, want nil", got)
+	}
+
+	store, _ := Load("", nil)
+	_ = store.Add(Subscription{ChannelID: "111", Type: disastersv1.DisasterType_FLOOD, MinAlertLevel: disastersv1.AlertLevel_RED})
+	_ = store.Add(Subscription{ChannelID: "222"})
+
+	got := store.Match(&disastersv1.Disaster{Type: disastersv1.DisasterType_FLOOD, AlertLevel: disastersv1.AlertLevel_ORANGE})
+	if len(got) != 1 || got[0].ChannelID != "222" {
+		t.Errorf("Match() = %+v, want only channel 222", got)
+	}
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	if err := os.WriteFile(path, []byte(`[{"channel_id":"1","type":"BLIZZARD"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path, nil); err == nil {
+		t.Error("Load() error = nil, want error for unknown type")
+	}
+}
+
+func TestSubscription_MatchesArea(t *testing.T) {
+	sub := Subscription{
+		Area: geo.Circle{Label: "tokyo", Center: geo.Point{Lat: 35.6762, Lon: 139.6503}, RadiusKm: 100},
+	}
+
+	if !sub.Matches(&disastersv1.Disaster{Latitude: 35.5, Longitude: 139.7}) {
+		t.Error("Matches() = false for disaster inside area")
+	}
+	if sub.Matches(&disastersv1.Disaster{Latitude: 34.6937, Longitude: 135.5023}) {
+		t.Error("Matches() = true for disaster outside area")
+	}
+}
+
+func TestStore_PersistsAreas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	polygons := map[string]*geo.Polygon{
+		"office": {Label: "office", Rings: [][]geo.Point{{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}, {Lat: 1, Lon: 1}, {Lat: 0, Lon: 0}}}},
+	}
+
+	store, _ := Load(path, polygons)
+	circle := Subscription{ChannelID: "111", Area: geo.Circle{Label: "tokyo", Center: geo.Point{Lat: 35.6, Lon: 139.6}, RadiusKm: 50}}
+	polygon := Subscription{ChannelID: "111", Area: polygons["office"]}
+	if err := store.Add(circle); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := store.Add(polygon); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	reloaded, err := Load(path, polygons)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	subs := reloaded.List("111")
+	if len(subs) != 2 || subs[0] != circle || subs[1] != polygon {
+		t.Errorf("List() = %+v, want [%+v %+v]", subs, circle, polygon)
+	}
+
+	if _, err := Load(path, nil); err == nil {
+		t.Error("Load() error = nil, want error for unknown polygon area")
+	}
+}
@@ -0,0 +1,33 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		exporter string
+		wantErr  bool
+	}{
+		{ExporterNone, false},
+		{ExporterStdout, false},
+		{ExporterOTLP, false}, // Connects lazily, so no collector is needed
+		{"jaeger", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exporter, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), tt.exporter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Setup(%q) error = %v, wantErr %v", tt.exporter, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("shutdown() error = %v", err)
+			}
+		})
+	}
+}